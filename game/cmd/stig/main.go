@@ -0,0 +1,172 @@
+// Command stig plays, records, evaluates and replays laps of the Horizon Drive game.
+//
+// Each command is configured by its environment variables, overridden by its flags:
+//
+//	stig play --agent-url http://localhost:8080 --workers-num 2
+//	stig play --help
+//	stig config print play
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/nizarmah/stig/game/internal/commands/evaluate"
+	"github.com/nizarmah/stig/game/internal/commands/mock"
+	"github.com/nizarmah/stig/game/internal/commands/play"
+	"github.com/nizarmah/stig/game/internal/commands/record"
+	"github.com/nizarmah/stig/game/internal/commands/replay"
+	"github.com/nizarmah/stig/game/internal/commands/tournament"
+	"github.com/nizarmah/stig/game/internal/env"
+)
+
+// command is a subcommand of stig.
+type command struct {
+	// name is the name of the command.
+	name string
+	// summary is the one-line description of the command.
+	summary string
+	// load creates the configuration of the command from the environment and the args.
+	load func(args []string) (any, error)
+	// run runs the command with its configuration.
+	run func(ctx context.Context, cfg any)
+}
+
+// newCommand creates a command running with a configuration of type E.
+func newCommand[E any](name string, summary string, run func(context.Context, *E)) command {
+	return command{
+		name:    name,
+		summary: summary,
+		load: func(args []string) (any, error) {
+			cfg := new(E)
+			if err := env.Load(cfg, "stig "+name, args, os.Stderr); err != nil {
+				return nil, err
+			}
+
+			return cfg, nil
+		},
+		run: func(ctx context.Context, cfg any) {
+			run(ctx, cfg.(*E))
+		},
+	}
+}
+
+// commands are the subcommands of stig.
+var commands = []command{
+	newCommand("play", "play laps with an agent", play.Run),
+	newCommand("record", "record laps driven by a human", record.Run),
+	newCommand("eval", "score an agent and gate it on thresholds", evaluate.Run),
+	newCommand("replay", "drive the car with the actions of a recorded lap", replay.Run),
+	newCommand("tournament", "rank agents by interleaving their laps", tournament.Run),
+	newCommand("mockgame", "serve a stand-in for the game", mock.Run),
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+
+	name, args := os.Args[1], os.Args[2:]
+	switch name {
+	case "-h", "-help", "--help", "help":
+		usage(os.Stdout)
+		return
+
+	case "config":
+		if len(args) < 2 || args[0] != "print" {
+			log.Fatalf("usage: stig config print <command> [flags]")
+		}
+
+		cmd := find(args[1])
+		cfg := load(cmd, args[2:])
+
+		if err := printConfig(os.Stdout, cfg); err != nil {
+			log.Fatalf("failed to print config: %v", err)
+		}
+
+		return
+	}
+
+	cmd := find(name)
+	cfg := load(cmd, args)
+
+	// Context.
+	ctx, cancel := signal.NotifyContext(
+		context.Background(),
+		syscall.SIGINT, syscall.SIGTERM, syscall.SIGKILL,
+	)
+	defer cancel()
+
+	cmd.run(ctx, cfg)
+}
+
+// find returns the command with the name, or exits.
+func find(name string) command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+
+	usage(os.Stderr)
+	log.Fatalf("unknown command %q", name)
+	return command{}
+}
+
+// load loads the configuration of the command, or exits.
+func load(cmd command, args []string) any {
+	cfg, err := cmd.load(args)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+
+	if err != nil {
+		log.Fatalf("failed to load %s config: %v", cmd.name, err)
+	}
+
+	return cfg
+}
+
+// printConfig prints the settings of the configuration as an env file.
+func printConfig(w io.Writer, cfg any) error {
+	settings, err := env.Settings(cfg)
+	if err != nil {
+		return err
+	}
+
+	slices.SortFunc(settings, func(a, b *env.Setting) int {
+		return strings.Compare(a.Env, b.Env)
+	})
+
+	for _, s := range settings {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", s.Env, s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// usage prints the commands of stig.
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: stig <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-12s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "  %-12s %s\n", "config print", "print the configuration of a command as env vars")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags override the environment variables, which override the defaults.")
+	fmt.Fprintln(w, "Run 'stig <command> --help' for the settings of a command.")
+}
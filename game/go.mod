@@ -2,12 +2,16 @@ module github.com/nizarmah/stig/game
 
 go 1.24.3
 
-require github.com/go-rod/rod v0.116.2
+require (
+	github.com/go-rod/rod v0.116.2
+	github.com/gorilla/websocket v1.5.3
+	github.com/ysmood/gson v0.7.3
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/ysmood/fetchup v0.3.0 // indirect
 	github.com/ysmood/goob v0.4.0 // indirect
 	github.com/ysmood/got v0.40.0 // indirect
-	github.com/ysmood/gson v0.7.3 // indirect
 	github.com/ysmood/leakless v0.9.0 // indirect
 )
@@ -1,82 +1,63 @@
-// Package agent provides the agent that plays the game.
+// Package agent provides the agents that play the game.
 package agent
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"log"
-	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/nizarmah/stig/game/internal/game"
 )
 
-// ClientConfiguration is the configuration for the agent.
-type ClientConfiguration struct {
-	// APIURL is the URL of the agent API.
-	APIURL string
-	// Debug is whether to debug the agent client.
-	Debug bool
-	// Timeout is the timeout for the agent to act.
-	Timeout time.Duration
+// Agent decides what action to take on an observation of the game.
+type Agent interface {
+	// Act returns the action to take on the given observation.
+	Act(ctx context.Context, obs Observation) (game.Action, error)
 }
 
-// Client is the agent that plays the game.
-type Client struct {
-	apiURL  string
-	debug   bool
-	timeout time.Duration
+// Observation is what the agent sees of the game on a single tick.
+type Observation struct {
+	// Frame is the JPEG-encoded screenshot of the game.
+	Frame []byte
+	// Tick is the index of the tick within the lap.
+	Tick int
+	// Time is when the frame was captured.
+	Time time.Time
 }
 
-// NewClient creates a new client.
-func NewClient(cfg ClientConfiguration) *Client {
-	return &Client{
-		apiURL:  cfg.APIURL,
-		debug:   cfg.Debug,
-		timeout: cfg.Timeout,
-	}
-}
-
-// Act returns the action to take on the given frame.
-func (c *Client) Act(frame []byte) (game.Action, error) {
-	url := fmt.Sprintf("%s/act", c.apiURL)
+// Func is an in-process agent backed by a function.
+type Func func(ctx context.Context, obs Observation) (game.Action, error)
 
-	// Prepare the request.
-	req, _ := http.NewRequest("POST", url, bytes.NewReader(frame))
-	req.Header.Set("Content-Type", "image/jpeg")
-
-	// Send the request.
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		if c.debug {
-			log.Println(fmt.Sprintf("agent failed to send request: %v", err))
-		}
+// Act returns the action to take on the given observation.
+func (f Func) Act(ctx context.Context, obs Observation) (game.Action, error) {
+	return f(ctx, obs)
+}
 
-		return game.Action{}, fmt.Errorf("failed to send request: %w", err)
+// Close closes the connection an agent keeps open, if any, such as the WebSocket of a stream agent.
+// Ensembles close the connections of their members.
+func Close(a Agent) {
+	if closer, ok := a.(interface{ Close() }); ok {
+		closer.Close()
 	}
+}
 
-	if resp.StatusCode != http.StatusOK {
-		if c.debug {
-			log.Println(fmt.Sprintf("agent failed to send request: %v", resp.StatusCode))
-		}
-
-		return game.Action{}, fmt.Errorf("failed to send request: %v", resp.StatusCode)
+// New creates the agent for the configured API URL.
+// The scheme of the URL selects the transport:
+// "ws" and "wss" stream frames over a WebSocket, "http" and "https" post each frame.
+func New(cfg ClientConfiguration) (Agent, error) {
+	apiURL, err := url.Parse(cfg.APIURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse agent url: %w", err)
 	}
 
-	// Parse the response.
-	action := game.Action{}
-	if err := json.NewDecoder(resp.Body).Decode(&action); err != nil {
-		if c.debug {
-			log.Println(fmt.Sprintf("agent failed to decode response: body: %s, err: %v", resp.Body, err))
-		}
-
-		return game.Action{}, fmt.Errorf("failed to decode response: %w", err)
-	}
+	switch apiURL.Scheme {
+	case "ws", "wss":
+		return NewStream(cfg), nil
 
-	if c.debug {
-		log.Println(fmt.Sprintf("agent action: %+v", action))
+	case "http", "https":
+		return NewClient(cfg), nil
 	}
 
-	return action, nil
+	return nil, fmt.Errorf("unsupported agent url scheme %q", apiURL.Scheme)
 }
@@ -0,0 +1,141 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/game"
+	"github.com/nizarmah/stig/game/internal/metrics"
+)
+
+// ClientConfiguration is the configuration for the agent.
+type ClientConfiguration struct {
+	// APIURL is the URL of the agent API.
+	APIURL string
+	// Debug is whether to debug the agent client.
+	Debug bool
+	// Metrics records the latency and errors of the agent, if set.
+	Metrics *metrics.Metrics
+	// Timeout is the timeout for the agent to act.
+	Timeout time.Duration
+}
+
+// Client is the agent that plays the game through the agent API.
+type Client struct {
+	apiURL  string
+	debug   bool
+	metrics *metrics.Metrics
+	timeout time.Duration
+}
+
+// NewClient creates a new client.
+func NewClient(cfg ClientConfiguration) *Client {
+	return &Client{
+		apiURL:  cfg.APIURL,
+		debug:   cfg.Debug,
+		metrics: cfg.Metrics,
+		timeout: cfg.Timeout,
+	}
+}
+
+// Act returns the action to take on the given observation.
+func (c *Client) Act(ctx context.Context, obs Observation) (game.Action, error) {
+	start := time.Now()
+	action, err := c.act(ctx, obs)
+	observe(c.metrics, time.Since(start), err)
+
+	return action, err
+}
+
+// act asks the agent API for the action to take on the given observation.
+func (c *Client) act(ctx context.Context, obs Observation) (game.Action, error) {
+	url := fmt.Sprintf("%s/act", c.apiURL)
+
+	// Bound the request by the agent timeout.
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	// Prepare the request.
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(obs.Frame))
+	if err != nil {
+		return game.Action{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "image/jpeg")
+
+	// Send the request.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		if c.debug {
+			log.Println(fmt.Sprintf("agent failed to send request: %v", err))
+		}
+
+		return game.Action{}, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		if c.debug {
+			log.Println(fmt.Sprintf("agent failed to send request: %v", resp.StatusCode))
+		}
+
+		return game.Action{}, fmt.Errorf("failed to send request: %v", resp.StatusCode)
+	}
+
+	// Parse the response.
+	action := game.Action{}
+	if err := json.NewDecoder(resp.Body).Decode(&action); err != nil {
+		if c.debug {
+			log.Println(fmt.Sprintf("agent failed to decode response: %v", err))
+		}
+
+		return game.Action{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if c.debug {
+		log.Println(fmt.Sprintf("agent action: %+v", action))
+	}
+
+	return action, nil
+}
+
+// observe records the latency of an agent on a frame, or the kind of error it failed with.
+func observe(m *metrics.Metrics, latency time.Duration, err error) {
+	if err == nil {
+		m.ObserveAgent(latency)
+		return
+	}
+
+	m.AgentError(errorKind(err))
+}
+
+// errorKind classifies an agent error for metrics.
+func errorKind(err error) string {
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+
+	case errors.Is(err, errSuperseded):
+		return "superseded"
+
+	case errors.As(err, &netErr):
+		return "network"
+
+	default:
+		return "response"
+	}
+}
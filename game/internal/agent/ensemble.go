@@ -0,0 +1,260 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/game"
+)
+
+// EnsembleStrategy decides how the ensemble combines the actions of its members.
+type EnsembleStrategy = string
+
+const (
+	// EnsembleVote takes the majority vote per axis, breaking ties by member order.
+	EnsembleVote EnsembleStrategy = "vote"
+	// EnsemblePriority takes the action of the first member in order that answered.
+	EnsemblePriority EnsembleStrategy = "priority"
+	// EnsembleFirst takes the action of the first member to answer.
+	// The others are still awaited in the background, to log their disagreement.
+	EnsembleFirst EnsembleStrategy = "first"
+)
+
+// EnsembleMember is a member of the ensemble.
+type EnsembleMember struct {
+	// Agent is the agent of the member.
+	Agent Agent
+	// Name is the name of the member in logs.
+	Name string
+}
+
+// EnsembleConfiguration is the configuration for the ensemble agent.
+type EnsembleConfiguration struct {
+	// Debug is whether to debug the ensemble agent.
+	Debug bool
+	// Members are the members of the ensemble, in priority order.
+	Members []EnsembleMember
+	// Strategy is how the actions of the members are combined.
+	Strategy EnsembleStrategy
+	// Timeout is the deadline for the members to answer.
+	Timeout time.Duration
+}
+
+// Ensemble is an agent that asks several agents and combines their actions.
+type Ensemble struct {
+	debug    bool
+	members  []EnsembleMember
+	strategy EnsembleStrategy
+	timeout  time.Duration
+}
+
+// memberResult is the answer of a member.
+type memberResult struct {
+	index  int
+	action game.Action
+	err    error
+}
+
+// NewEnsemble creates a new ensemble agent.
+func NewEnsemble(cfg EnsembleConfiguration) (*Ensemble, error) {
+	if len(cfg.Members) == 0 {
+		return nil, errors.New("ensemble has no members")
+	}
+
+	return &Ensemble{
+		debug:    cfg.Debug,
+		members:  cfg.Members,
+		strategy: cfg.Strategy,
+		timeout:  cfg.Timeout,
+	}, nil
+}
+
+// Act sends the observation to all members concurrently and combines their actions.
+// Members that fail or miss the deadline are left out.
+func (e *Ensemble) Act(ctx context.Context, obs Observation) (game.Action, error) {
+	// Bound the members by the ensemble timeout, and stop them once the ensemble is done with them.
+	var cancel context.CancelFunc
+	if e.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+
+	results := make(chan memberResult, len(e.members))
+	for i, member := range e.members {
+		go func() {
+			action, err := member.Agent.Act(ctx, obs)
+			results <- memberResult{index: i, action: action, err: err}
+		}()
+	}
+
+	if e.strategy == EnsembleFirst {
+		return e.actFirst(obs, results, cancel)
+	}
+
+	defer cancel()
+
+	// Collect the answers.
+	answers := make([]*game.Action, len(e.members))
+	errs := make([]error, 0, len(e.members))
+
+	for range e.members {
+		res := <-results
+		if res.err != nil {
+			errs = append(errs, fmt.Errorf("member %s: %w", e.members[res.index].Name, res.err))
+			continue
+		}
+
+		answers[res.index] = &res.action
+	}
+
+	action, ok := e.combine(answers)
+	if !ok {
+		return game.Action{}, fmt.Errorf("no member answered: %w", errors.Join(errs...))
+	}
+
+	if disagreement := e.disagreement(answers); disagreement != "" {
+		log.Println(fmt.Sprintf("ensemble disagreement on tick %d: %s -> %+v", obs.Tick, disagreement, action))
+	}
+
+	return action, nil
+}
+
+// actFirst returns the action of the first member to answer, without waiting for the others.
+// The others are still collected in the background, until they answer or miss the deadline,
+// so their disagreement is logged like with the other strategies.
+func (e *Ensemble) actFirst(
+	obs Observation,
+	results <-chan memberResult,
+	cancel context.CancelFunc,
+) (game.Action, error) {
+	answers := make([]*game.Action, len(e.members))
+	errs := make([]error, 0, len(e.members))
+
+	for received := range len(e.members) {
+		res := <-results
+		if res.err != nil {
+			errs = append(errs, fmt.Errorf("member %s: %w", e.members[res.index].Name, res.err))
+			continue
+		}
+
+		if e.debug {
+			log.Println(fmt.Sprintf("ensemble took action of %s on tick %d: %+v", e.members[res.index].Name, obs.Tick, res.action))
+		}
+
+		answers[res.index] = &res.action
+
+		go func() {
+			defer cancel()
+
+			for range len(e.members) - received - 1 {
+				late := <-results
+				if late.err == nil {
+					answers[late.index] = &late.action
+				}
+			}
+
+			if disagreement := e.disagreement(answers); disagreement != "" {
+				log.Println(fmt.Sprintf("ensemble disagreement on tick %d: %s -> %+v", obs.Tick, disagreement, res.action))
+			}
+		}()
+
+		return res.action, nil
+	}
+
+	cancel()
+
+	return game.Action{}, fmt.Errorf("no member answered: %w", errors.Join(errs...))
+}
+
+// Close closes the connections of the members.
+func (e *Ensemble) Close() {
+	for _, member := range e.members {
+		Close(member.Agent)
+	}
+}
+
+// combine combines the answers of the members according to the strategy.
+func (e *Ensemble) combine(answers []*game.Action) (game.Action, bool) {
+	if e.strategy == EnsembleVote {
+		throttles := make([]string, 0, len(answers))
+		steerings := make([]string, 0, len(answers))
+		for _, answer := range answers {
+			if answer != nil {
+				throttles = append(throttles, answer.Throttle)
+				steerings = append(steerings, answer.Steering)
+			}
+		}
+
+		if len(throttles) == 0 {
+			return game.Action{}, false
+		}
+
+		return game.Action{
+			Throttle: vote(throttles),
+			Steering: vote(steerings),
+		}, true
+	}
+
+	for _, answer := range answers {
+		if answer != nil {
+			return *answer, true
+		}
+	}
+
+	return game.Action{}, false
+}
+
+// disagreement describes the answers of the members when they disagree.
+func (e *Ensemble) disagreement(answers []*game.Action) string {
+	var first *game.Action
+	agree := true
+	for _, answer := range answers {
+		if answer == nil {
+			continue
+		}
+
+		if first == nil {
+			first = answer
+		} else if *answer != *first {
+			agree = false
+		}
+	}
+
+	if agree {
+		return ""
+	}
+
+	parts := make([]string, 0, len(answers))
+	for i, answer := range answers {
+		if answer == nil {
+			parts = append(parts, fmt.Sprintf("%s: none", e.members[i].Name))
+			continue
+		}
+
+		parts = append(parts, fmt.Sprintf("%s: %+v", e.members[i].Name, *answer))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// vote returns the most common value, breaking ties by order.
+func vote(values []string) string {
+	counts := make(map[string]int, len(values))
+	for _, value := range values {
+		counts[value]++
+	}
+
+	best := values[0]
+	for _, value := range values {
+		if counts[value] > counts[best] {
+			best = value
+		}
+	}
+
+	return best
+}
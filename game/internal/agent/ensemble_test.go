@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/game"
+)
+
+func TestEnsembleFirstLogsLateDisagreement(t *testing.T) {
+	logs := &syncBuffer{}
+	log.SetOutput(logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	accelerate := game.Action{Throttle: game.ThrottleAccelerate}
+	brake := game.Action{Throttle: game.ThrottleBrake}
+
+	ensemble, err := NewEnsemble(EnsembleConfiguration{
+		Members: []EnsembleMember{
+			{
+				Name: "fast",
+				Agent: Func(func(context.Context, Observation) (game.Action, error) {
+					return accelerate, nil
+				}),
+			},
+			{
+				Name: "slow",
+				Agent: Func(func(context.Context, Observation) (game.Action, error) {
+					time.Sleep(50 * time.Millisecond)
+					return brake, nil
+				}),
+			},
+		},
+		Strategy: EnsembleFirst,
+		Timeout:  time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewEnsemble() error = %v", err)
+	}
+
+	start := time.Now()
+
+	action, err := ensemble.Act(context.Background(), Observation{Tick: 7})
+	if err != nil {
+		t.Fatalf("Act() error = %v", err)
+	}
+
+	if action != accelerate {
+		t.Errorf("Act() = %+v, want the first answer %+v", action, accelerate)
+	}
+
+	if elapsed := time.Since(start); elapsed >= 50*time.Millisecond {
+		t.Errorf("Act() took %s, want it not to wait for the slow member", elapsed)
+	}
+
+	// The slow member's answer is logged once it comes in.
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(logs.String(), "ensemble disagreement on tick 7") {
+		if time.Now().After(deadline) {
+			t.Fatalf("late disagreement wasn't logged, logs:\n%s", logs.String())
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if got := logs.String(); !strings.Contains(got, "slow: {Throttle:brake") {
+		t.Errorf("disagreement is missing the slow member's answer:\n%s", got)
+	}
+}
+
+// syncBuffer is a buffer safe to write and read concurrently.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
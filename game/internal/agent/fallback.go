@@ -0,0 +1,140 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/nizarmah/stig/game/internal/game"
+)
+
+// FallbackPolicy decides what to do when the agent fails to act in time.
+type FallbackPolicy = string
+
+const (
+	// FallbackHold holds the last action the agent took.
+	FallbackHold FallbackPolicy = "hold"
+	// FallbackNeutral releases all keys and lets the car coast.
+	FallbackNeutral FallbackPolicy = "neutral"
+	// FallbackBrake brakes while keeping the car straight.
+	FallbackBrake FallbackPolicy = "brake"
+)
+
+// FallbackConfiguration is the configuration for the fallback agent.
+type FallbackConfiguration struct {
+	// Agent is the agent to fall back from.
+	Agent Agent
+	// Debug is whether to debug the fallback agent.
+	Debug bool
+	// Policy is the policy to apply when the agent fails.
+	Policy FallbackPolicy
+}
+
+// FallbackStats counts how often the fallback policy was applied.
+type FallbackStats struct {
+	// Errors is the number of times the agent failed.
+	Errors int
+	// Timeouts is the number of times the agent missed its deadline.
+	Timeouts int
+}
+
+// Total returns the total number of fallbacks.
+func (s FallbackStats) Total() int {
+	return s.Errors + s.Timeouts
+}
+
+// Fallback is an agent that applies a fallback policy when its agent fails.
+type Fallback struct {
+	// agent is the agent to fall back from.
+	agent Agent
+	// debug is whether to debug the fallback agent.
+	debug bool
+	// policy is the policy to apply when the agent fails.
+	policy FallbackPolicy
+
+	// mu guards last and stats.
+	mu sync.Mutex
+	// last is the last action the agent took.
+	last game.Action
+	// stats counts the fallbacks.
+	stats FallbackStats
+}
+
+// NewFallback creates a new fallback agent.
+func NewFallback(cfg FallbackConfiguration) *Fallback {
+	return &Fallback{
+		agent:  cfg.Agent,
+		debug:  cfg.Debug,
+		policy: cfg.Policy,
+	}
+}
+
+// Act returns the agent's action, or the fallback action if the agent fails.
+// Errors caused by the parent context ending are returned as is.
+func (f *Fallback) Act(ctx context.Context, obs Observation) (game.Action, error) {
+	action, err := f.agent.Act(ctx, obs)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err == nil {
+		f.last = action
+		return action, nil
+	}
+
+	if ctx.Err() != nil {
+		return game.Action{}, err
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		f.stats.Timeouts++
+	} else {
+		f.stats.Errors++
+	}
+
+	action = f.fallback()
+	if f.debug {
+		log.Println(fmt.Sprintf("agent fell back to %q: action: %+v, err: %v", f.policy, action, err))
+	}
+
+	return action, nil
+}
+
+// Stats returns the fallbacks counted since the last reset.
+func (f *Fallback) Stats() FallbackStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.stats
+}
+
+// Reset clears the fallback counts and the last action.
+func (f *Fallback) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.last = game.Action{}
+	f.stats = FallbackStats{}
+}
+
+// fallback returns the action to take according to the policy.
+func (f *Fallback) fallback() game.Action {
+	switch f.policy {
+	case FallbackHold:
+		return f.last
+
+	case FallbackBrake:
+		return game.Action{
+			Throttle: game.ThrottleBrake,
+			Steering: game.SteeringStraight,
+		}
+
+	default:
+		return game.Action{
+			Throttle: game.ThrottleNeutral,
+			Steering: game.SteeringStraight,
+		}
+	}
+}
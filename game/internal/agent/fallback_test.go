@@ -0,0 +1,156 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nizarmah/stig/game/internal/game"
+)
+
+func TestFallbackAct(t *testing.T) {
+	last := game.Action{Throttle: game.ThrottleAccelerate, Steering: game.SteeringLeft}
+	failure := errors.New("agent failed")
+
+	tests := []struct {
+		name   string
+		policy FallbackPolicy
+		err    error
+		want   game.Action
+		stats  FallbackStats
+	}{
+		{
+			name:   "hold on error",
+			policy: FallbackHold,
+			err:    failure,
+			want:   last,
+			stats:  FallbackStats{Errors: 1},
+		},
+		{
+			name:   "neutral on error",
+			policy: FallbackNeutral,
+			err:    failure,
+			want:   game.Action{Throttle: game.ThrottleNeutral, Steering: game.SteeringStraight},
+			stats:  FallbackStats{Errors: 1},
+		},
+		{
+			name:   "brake on error",
+			policy: FallbackBrake,
+			err:    failure,
+			want:   game.Action{Throttle: game.ThrottleBrake, Steering: game.SteeringStraight},
+			stats:  FallbackStats{Errors: 1},
+		},
+		{
+			name:   "hold on timeout",
+			policy: FallbackHold,
+			err:    context.DeadlineExceeded,
+			want:   last,
+			stats:  FallbackStats{Timeouts: 1},
+		},
+		{
+			name:   "wrapped timeout",
+			policy: FallbackBrake,
+			err:    errors.Join(failure, context.DeadlineExceeded),
+			want:   game.Action{Throttle: game.ThrottleBrake, Steering: game.SteeringStraight},
+			stats:  FallbackStats{Timeouts: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+
+			fallback := NewFallback(FallbackConfiguration{
+				Agent: Func(func(context.Context, Observation) (game.Action, error) {
+					if err != nil {
+						return game.Action{}, err
+					}
+
+					return last, nil
+				}),
+				Policy: tt.policy,
+			})
+
+			// The first action succeeds, so the hold policy has one to hold.
+			if _, err := fallback.Act(context.Background(), Observation{}); err != nil {
+				t.Fatalf("Act() error = %v", err)
+			}
+
+			err = tt.err
+
+			action, actErr := fallback.Act(context.Background(), Observation{})
+			if actErr != nil {
+				t.Fatalf("Act() error = %v, want the fallback action", actErr)
+			}
+
+			if action != tt.want {
+				t.Errorf("Act() = %+v, want %+v", action, tt.want)
+			}
+
+			if got := fallback.Stats(); got != tt.stats {
+				t.Errorf("Stats() = %+v, want %+v", got, tt.stats)
+			}
+		})
+	}
+}
+
+func TestFallbackActReturnsParentContextErrors(t *testing.T) {
+	fallback := NewFallback(FallbackConfiguration{
+		Agent: Func(func(ctx context.Context, _ Observation) (game.Action, error) {
+			<-ctx.Done()
+			return game.Action{}, ctx.Err()
+		}),
+		Policy: FallbackBrake,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	action, err := fallback.Act(ctx, Observation{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Act() error = %v, want %v", err, context.Canceled)
+	}
+
+	if action != (game.Action{}) {
+		t.Errorf("Act() = %+v, want no action", action)
+	}
+
+	if got := fallback.Stats(); got.Total() != 0 {
+		t.Errorf("Stats() = %+v, want no fallbacks", got)
+	}
+}
+
+func TestFallbackReset(t *testing.T) {
+	fail := false
+
+	fallback := NewFallback(FallbackConfiguration{
+		Agent: Func(func(context.Context, Observation) (game.Action, error) {
+			if fail {
+				return game.Action{}, context.DeadlineExceeded
+			}
+
+			return game.Action{Throttle: game.ThrottleAccelerate}, nil
+		}),
+		Policy: FallbackHold,
+	})
+
+	if _, err := fallback.Act(context.Background(), Observation{}); err != nil {
+		t.Fatalf("Act() error = %v", err)
+	}
+
+	fallback.Reset()
+	fail = true
+
+	action, err := fallback.Act(context.Background(), Observation{})
+	if err != nil {
+		t.Fatalf("Act() error = %v", err)
+	}
+
+	if action != (game.Action{}) {
+		t.Errorf("Act() = %+v, want nothing to hold after a reset", action)
+	}
+
+	if got := fallback.Stats(); got != (FallbackStats{Timeouts: 1}) {
+		t.Errorf("Stats() = %+v, want the timeout since the reset", got)
+	}
+}
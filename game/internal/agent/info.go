@@ -0,0 +1,259 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/game"
+)
+
+// imageFormat is the format of the frames sent to the agents.
+const imageFormat = "jpeg"
+
+// Info describes an agent.
+type Info struct {
+	// Actions are the actions the agent can take.
+	Actions ActionsInfo `json:"actions"`
+	// Image is the image the agent expects.
+	Image ImageInfo `json:"image"`
+	// Model is the name of the model behind the agent.
+	Model string `json:"model"`
+}
+
+// ActionsInfo describes the actions an agent can take.
+type ActionsInfo struct {
+	// Steering are the steering states the agent can take.
+	Steering []game.Steering `json:"steering"`
+	// Throttle are the throttle states the agent can take.
+	Throttle []game.Throttle `json:"throttle"`
+}
+
+// ImageInfo describes the image an agent expects.
+type ImageInfo struct {
+	// Format is the format of the image.
+	Format string `json:"format"`
+	// Height is the height the agent resizes the image to.
+	Height int `json:"height"`
+	// Width is the width the agent resizes the image to.
+	Width int `json:"width"`
+}
+
+// Frame is the size of the frames captured from the game.
+type Frame struct {
+	// Height is the height of the frames.
+	Height int
+	// Width is the width of the frames.
+	Width int
+}
+
+// String formats the info for logs.
+func (i Info) String() string {
+	if i.Image.Width == 0 || i.Image.Height == 0 {
+		return fmt.Sprintf("%s (%s)", i.Model, i.Image.Format)
+	}
+
+	return fmt.Sprintf("%s (%s %dx%d)", i.Model, i.Image.Format, i.Image.Width, i.Image.Height)
+}
+
+// Validate checks the agent is compatible with the game.
+func (i Info) Validate() error {
+	errs := []error{}
+
+	if i.Model == "" {
+		errs = append(errs, errors.New("agent has no model name"))
+	}
+
+	if i.Image.Format != imageFormat {
+		errs = append(errs, fmt.Errorf("agent expects %q images, but frames are %q", i.Image.Format, imageFormat))
+	}
+
+	for _, throttle := range i.Actions.Throttle {
+		if _, ok := game.ThrottleStateMap[throttle]; !ok && throttle != game.ThrottleNeutral {
+			errs = append(errs, fmt.Errorf("agent has unknown throttle state %q", throttle))
+		}
+	}
+
+	for _, steering := range i.Actions.Steering {
+		if _, ok := game.SteeringStateMap[steering]; !ok && steering != game.SteeringStraight {
+			errs = append(errs, fmt.Errorf("agent has unknown steering state %q", steering))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// CheckFrame checks the agent can resize the frames to the image it expects.
+// Agents that don't say which size they expect take any frame.
+func (i Info) CheckFrame(frame Frame) error {
+	if i.Image.Width == 0 || i.Image.Height == 0 {
+		return nil
+	}
+
+	if i.Image.Width > frame.Width || i.Image.Height > frame.Height {
+		return fmt.Errorf("agent expects %dx%d images, larger than the %dx%d frames", i.Image.Width, i.Image.Height, frame.Width, frame.Height)
+	}
+
+	// Allow a pixel of rounding, since the agent's size is a fraction of the frame.
+	height := math.Round(float64(i.Image.Width) * float64(frame.Height) / float64(frame.Width))
+	if math.Abs(height-float64(i.Image.Height)) > 1 {
+		return fmt.Errorf("agent expects %dx%d images, but frames are %dx%d", i.Image.Width, i.Image.Height, frame.Width, frame.Height)
+	}
+
+	return nil
+}
+
+// Describer is an agent that can describe itself.
+type Describer interface {
+	// Info returns the description of the agent.
+	Info(ctx context.Context) (Info, error)
+}
+
+// Handshake describes the agent and checks it is compatible with the game and its frames.
+func Handshake(ctx context.Context, a Agent, frame Frame) (Info, error) {
+	info, err := describe(ctx, a)
+	if err != nil {
+		return Info{}, err
+	}
+
+	if err := info.CheckFrame(frame); err != nil {
+		return Info{}, fmt.Errorf("agent %s is not compatible: %w", info.Model, err)
+	}
+
+	return info, nil
+}
+
+// describe describes the agent and checks it is compatible with the game.
+// Agents that can't describe themselves, like in-process ones, are named after their type.
+func describe(ctx context.Context, a Agent) (Info, error) {
+	describer, ok := a.(Describer)
+	if !ok {
+		return Info{
+			Image: ImageInfo{Format: imageFormat},
+			Model: fmt.Sprintf("%T", a),
+		}, nil
+	}
+
+	info, err := describer.Info(ctx)
+	if err != nil {
+		return Info{}, fmt.Errorf("failed to describe agent: %w", err)
+	}
+
+	if err := info.Validate(); err != nil {
+		return Info{}, fmt.Errorf("agent %s is not compatible: %w", info.Model, err)
+	}
+
+	return info, nil
+}
+
+// Info returns the description of the agent.
+func (c *Client) Info(ctx context.Context) (Info, error) {
+	return fetchInfo(ctx, c.apiURL, c.timeout)
+}
+
+// Info returns the description of the agent.
+func (s *Stream) Info(ctx context.Context) (Info, error) {
+	// The description is served over HTTP next to the stream.
+	apiURL := s.apiURL
+	if rest, ok := strings.CutPrefix(apiURL, "ws"); ok {
+		apiURL = "http" + rest
+	}
+
+	return fetchInfo(ctx, apiURL, s.timeout)
+}
+
+// Info returns the combined description of the members.
+func (e *Ensemble) Info(ctx context.Context) (Info, error) {
+	combined := Info{
+		Image: ImageInfo{Format: imageFormat},
+	}
+
+	models := make([]string, 0, len(e.members))
+	for _, member := range e.members {
+		info, err := describe(ctx, member.Agent)
+		if err != nil {
+			return Info{}, fmt.Errorf("member %s: %w", member.Name, err)
+		}
+
+		// The members see the same frames, so the ensemble expects the size they agree on.
+		if info.Image.Width != 0 && info.Image.Height != 0 {
+			if combined.Image.Width != 0 && (combined.Image.Width != info.Image.Width || combined.Image.Height != info.Image.Height) {
+				return Info{}, fmt.Errorf(
+					"member %s expects %dx%d images, but other members expect %dx%d",
+					member.Name, info.Image.Width, info.Image.Height, combined.Image.Width, combined.Image.Height,
+				)
+			}
+
+			combined.Image.Width, combined.Image.Height = info.Image.Width, info.Image.Height
+		}
+
+		models = append(models, info.Model)
+		combined.Actions.Throttle = union(combined.Actions.Throttle, info.Actions.Throttle)
+		combined.Actions.Steering = union(combined.Actions.Steering, info.Actions.Steering)
+	}
+
+	combined.Model = fmt.Sprintf("%s(%s)", e.strategy, strings.Join(models, ", "))
+
+	return combined, nil
+}
+
+// Info returns the description of the agent it falls back from.
+func (f *Fallback) Info(ctx context.Context) (Info, error) {
+	return describe(ctx, f.agent)
+}
+
+// Info returns the description of the agent the human overrides.
+func (o *Override) Info(ctx context.Context) (Info, error) {
+	return describe(ctx, o.agent)
+}
+
+// fetchInfo fetches the description of an agent from its API.
+func fetchInfo(ctx context.Context, apiURL string, timeout time.Duration) (Info, error) {
+	// Bound the request by the agent timeout, with room for a cold start.
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, 10*timeout)
+		defer cancel()
+	}
+
+	url := fmt.Sprintf("%s/info", apiURL)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return Info{}, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return Info{}, fmt.Errorf("failed to reach agent at %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Info{}, fmt.Errorf("failed to describe agent at %s: %v", url, resp.StatusCode)
+	}
+
+	info := Info{}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return Info{}, fmt.Errorf("failed to decode agent info: %w", err)
+	}
+
+	return info, nil
+}
+
+// union returns the values of both slices, without duplicates.
+func union(a, b []string) []string {
+	for _, value := range b {
+		if !slices.Contains(a, value) {
+			a = append(a, value)
+		}
+	}
+
+	return a
+}
@@ -0,0 +1,311 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/game"
+)
+
+// frame is the size of the frames the tests capture.
+var frame = Frame{Height: 600, Width: 960}
+
+// validInfo returns the description of an agent compatible with the game.
+func validInfo() Info {
+	return Info{
+		Actions: ActionsInfo{
+			Steering: []game.Steering{game.SteeringLeft, game.SteeringStraight, game.SteeringRight},
+			Throttle: []game.Throttle{game.ThrottleAccelerate, game.ThrottleNeutral, game.ThrottleBrake},
+		},
+		Image: ImageInfo{Format: "jpeg", Height: 200, Width: 320},
+		Model: "stig",
+	}
+}
+
+// newInfoServer serves the given description of an agent on /info.
+func newInfoServer(t *testing.T, info Info) string {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/info" {
+			http.NotFound(w, r)
+			return
+		}
+
+		if err := json.NewEncoder(w).Encode(info); err != nil {
+			t.Errorf("failed to encode info: %v", err)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server.URL
+}
+
+func TestInfoValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Info)
+		want   string
+	}{
+		{
+			name:   "valid",
+			modify: func(*Info) {},
+		},
+		{
+			name:   "no model",
+			modify: func(i *Info) { i.Model = "" },
+			want:   "agent has no model name",
+		},
+		{
+			name:   "other format",
+			modify: func(i *Info) { i.Image.Format = "png" },
+			want:   `agent expects "png" images, but frames are "jpeg"`,
+		},
+		{
+			name:   "unknown throttle",
+			modify: func(i *Info) { i.Actions.Throttle = append(i.Actions.Throttle, "boost") },
+			want:   `agent has unknown throttle state "boost"`,
+		},
+		{
+			name:   "unknown steering",
+			modify: func(i *Info) { i.Actions.Steering = append(i.Actions.Steering, "drift") },
+			want:   `agent has unknown steering state "drift"`,
+		},
+		{
+			name: "several errors",
+			modify: func(i *Info) {
+				i.Model = ""
+				i.Actions.Steering = []game.Steering{"drift"}
+			},
+			want: "agent has no model name\nagent has unknown steering state \"drift\"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := validInfo()
+			tt.modify(&info)
+
+			err := info.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+
+				return
+			}
+
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Validate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestInfoCheckFrame(t *testing.T) {
+	tests := []struct {
+		name  string
+		image ImageInfo
+		want  string
+	}{
+		{
+			name:  "scaled down",
+			image: ImageInfo{Height: 200, Width: 320},
+		},
+		{
+			name:  "same size",
+			image: ImageInfo{Height: 600, Width: 960},
+		},
+		{
+			name:  "rounded",
+			image: ImageInfo{Height: 63, Width: 100},
+		},
+		{
+			name: "no size",
+		},
+		{
+			name:  "larger",
+			image: ImageInfo{Height: 1200, Width: 1920},
+			want:  "agent expects 1920x1200 images, larger than the 960x600 frames",
+		},
+		{
+			name:  "other aspect ratio",
+			image: ImageInfo{Height: 224, Width: 224},
+			want:  "agent expects 224x224 images, but frames are 960x600",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := Info{Image: tt.image}
+
+			err := info.CheckFrame(frame)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("CheckFrame() error = %v, want nil", err)
+				}
+
+				return
+			}
+
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("CheckFrame() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandshake(t *testing.T) {
+	mismatched := validInfo()
+	mismatched.Image.Width = 200
+
+	invalid := validInfo()
+	invalid.Actions.Throttle = []game.Throttle{"boost"}
+
+	tests := []struct {
+		name string
+		info Info
+		want string
+	}{
+		{
+			name: "compatible",
+			info: validInfo(),
+		},
+		{
+			name: "invalid",
+			info: invalid,
+			want: `agent stig is not compatible: agent has unknown throttle state "boost"`,
+		},
+		{
+			name: "frame mismatch",
+			info: mismatched,
+			want: "agent stig is not compatible: agent expects 200x200 images, but frames are 960x600",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiURL := newInfoServer(t, tt.info)
+
+			for _, scheme := range []string{"http", "ws"} {
+				a, err := New(ClientConfiguration{
+					APIURL:  scheme + strings.TrimPrefix(apiURL, "http"),
+					Timeout: time.Second,
+				})
+				if err != nil {
+					t.Fatalf("New() error = %v", err)
+				}
+
+				info, err := Handshake(context.Background(), a, frame)
+				if tt.want != "" {
+					if err == nil || err.Error() != tt.want {
+						t.Errorf("Handshake() over %s error = %v, want %q", scheme, err, tt.want)
+					}
+
+					continue
+				}
+
+				if err != nil {
+					t.Fatalf("Handshake() over %s error = %v", scheme, err)
+				}
+
+				if info.Model != tt.info.Model || info.Image != tt.info.Image {
+					t.Errorf("Handshake() over %s = %s, want %s", scheme, info, tt.info)
+				}
+			}
+		})
+	}
+}
+
+func TestHandshakeUndescribedAgent(t *testing.T) {
+	a := Func(func(context.Context, Observation) (game.Action, error) {
+		return game.Action{}, nil
+	})
+
+	info, err := Handshake(context.Background(), a, frame)
+	if err != nil {
+		t.Fatalf("Handshake() error = %v", err)
+	}
+
+	if info.Model != "agent.Func" || info.Image.Format != imageFormat {
+		t.Errorf("Handshake() = %s, want the agent named after its type", info)
+	}
+}
+
+func TestHandshakeUnreachableAgent(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(server.Close)
+
+	a := NewClient(ClientConfiguration{APIURL: server.URL, Timeout: time.Second})
+
+	_, err := Handshake(context.Background(), a, frame)
+	if err == nil || !strings.HasPrefix(err.Error(), "failed to describe agent: ") {
+		t.Errorf("Handshake() error = %v, want a failure to describe the agent", err)
+	}
+}
+
+func TestEnsembleInfoImageSize(t *testing.T) {
+	small := validInfo()
+	small.Image.Height, small.Image.Width = 100, 160
+
+	tests := []struct {
+		name  string
+		infos []Info
+		want  string
+		image ImageInfo
+	}{
+		{
+			name:  "same size",
+			infos: []Info{validInfo(), validInfo()},
+			image: validInfo().Image,
+		},
+		{
+			name:  "different sizes",
+			infos: []Info{validInfo(), small},
+			want:  "member b expects 160x100 images, but other members expect 320x200",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			members := []EnsembleMember{}
+			for i, info := range tt.infos {
+				members = append(members, EnsembleMember{
+					Name:  string(rune('a' + i)),
+					Agent: NewClient(ClientConfiguration{APIURL: newInfoServer(t, info), Timeout: time.Second}),
+				})
+			}
+
+			ensemble, err := NewEnsemble(EnsembleConfiguration{
+				Members:  members,
+				Strategy: EnsembleFirst,
+				Timeout:  time.Second,
+			})
+			if err != nil {
+				t.Fatalf("NewEnsemble() error = %v", err)
+			}
+
+			info, err := ensemble.Info(context.Background())
+			if tt.want != "" {
+				if err == nil || err.Error() != tt.want {
+					t.Errorf("Info() error = %v, want %q", err, tt.want)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Info() error = %v", err)
+			}
+
+			if info.Image != tt.image {
+				t.Errorf("Info() image = %+v, want %+v", info.Image, tt.image)
+			}
+		})
+	}
+}
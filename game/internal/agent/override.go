@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/nizarmah/stig/game/internal/game"
+)
+
+// Human is the source of the actions of a human player.
+type Human interface {
+	// Peek returns the action the human is currently taking.
+	Peek() (game.Action, error)
+}
+
+// OverrideFunc is called on every tick the human overrides the agent,
+// with the action taken and the action the agent proposed.
+type OverrideFunc func(obs Observation, action, proposed game.Action) error
+
+// OverrideConfiguration is the configuration for the override agent.
+type OverrideConfiguration struct {
+	// Agent is the agent that drives unless the human takes over.
+	Agent Agent
+	// Debug is whether to debug the override agent.
+	Debug bool
+	// Human is the human that can take over.
+	Human Human
+	// OnOverride is called on every overridden tick, if set.
+	OnOverride OverrideFunc
+}
+
+// Override is an agent that lets a human take over the throttle or the steering
+// while they hold one of its keys.
+type Override struct {
+	agent      Agent
+	debug      bool
+	human      Human
+	onOverride OverrideFunc
+}
+
+// NewOverride creates a new override agent.
+func NewOverride(cfg OverrideConfiguration) *Override {
+	return &Override{
+		agent:      cfg.Agent,
+		debug:      cfg.Debug,
+		human:      cfg.Human,
+		onOverride: cfg.OnOverride,
+	}
+}
+
+// Act returns the agent's action, with the axes the human holds a key of taken from the human.
+// The agent is asked either way, so its proposal is known when the human takes over.
+func (o *Override) Act(ctx context.Context, obs Observation) (game.Action, error) {
+	proposed, err := o.agent.Act(ctx, obs)
+	if err != nil {
+		return game.Action{}, err
+	}
+
+	human, err := o.human.Peek()
+	if err != nil {
+		return game.Action{}, fmt.Errorf("failed to peek human action: %w", err)
+	}
+
+	// The human takes over the axes they hold a key of, and the agent keeps the others.
+	if human == (game.Action{}) {
+		return proposed, nil
+	}
+
+	action := proposed
+	if human.Throttle != game.ThrottleNeutral {
+		action.Throttle = human.Throttle
+	}
+
+	if human.Steering != game.SteeringStraight {
+		action.Steering = human.Steering
+	}
+
+	if o.debug {
+		log.Println(fmt.Sprintf("human took over on tick %d: human: %+v, agent: %+v, action: %+v", obs.Tick, human, proposed, action))
+	}
+
+	if o.onOverride != nil {
+		if err := o.onOverride(obs, action, proposed); err != nil {
+			log.Println(fmt.Sprintf("failed to handle override on tick %d: %v", obs.Tick, err))
+		}
+	}
+
+	return action, nil
+}
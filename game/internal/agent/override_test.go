@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nizarmah/stig/game/internal/game"
+)
+
+// humanFunc is a human backed by a function.
+type humanFunc func() (game.Action, error)
+
+// Peek returns the action the human is currently taking.
+func (f humanFunc) Peek() (game.Action, error) {
+	return f()
+}
+
+func TestOverrideAct(t *testing.T) {
+	proposed := game.Action{Throttle: game.ThrottleAccelerate, Steering: game.SteeringLeft}
+
+	tests := []struct {
+		name         string
+		human        game.Action
+		want         game.Action
+		wantOverride bool
+	}{
+		{
+			name:  "no keys held",
+			human: game.Action{},
+			want:  proposed,
+		},
+		{
+			name:         "throttle held",
+			human:        game.Action{Throttle: game.ThrottleBrake},
+			want:         game.Action{Throttle: game.ThrottleBrake, Steering: game.SteeringLeft},
+			wantOverride: true,
+		},
+		{
+			name:         "steering held",
+			human:        game.Action{Steering: game.SteeringRight},
+			want:         game.Action{Throttle: game.ThrottleAccelerate, Steering: game.SteeringRight},
+			wantOverride: true,
+		},
+		{
+			name:         "both held",
+			human:        game.Action{Throttle: game.ThrottleBrake, Steering: game.SteeringRight},
+			want:         game.Action{Throttle: game.ThrottleBrake, Steering: game.SteeringRight},
+			wantOverride: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var overridden, gotProposed game.Action
+			calls := 0
+
+			override := NewOverride(OverrideConfiguration{
+				Agent: Func(func(context.Context, Observation) (game.Action, error) {
+					return proposed, nil
+				}),
+				Human: humanFunc(func() (game.Action, error) {
+					return tt.human, nil
+				}),
+				OnOverride: func(_ Observation, action, proposed game.Action) error {
+					calls++
+					overridden, gotProposed = action, proposed
+					return nil
+				},
+			})
+
+			got, err := override.Act(context.Background(), Observation{})
+			if err != nil {
+				t.Fatalf("Act() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Act() = %+v, want %+v", got, tt.want)
+			}
+
+			if !tt.wantOverride {
+				if calls != 0 {
+					t.Errorf("OnOverride called %d times, want 0", calls)
+				}
+
+				return
+			}
+
+			if calls != 1 || overridden != tt.want || gotProposed != proposed {
+				t.Errorf("OnOverride(%+v, %+v) called %d times, want (%+v, %+v) once",
+					overridden, gotProposed, calls, tt.want, proposed)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/game"
+	"github.com/nizarmah/stig/game/internal/recording"
+)
+
+// ReplayStep is an action taken at an offset from the start of the lap.
+type ReplayStep struct {
+	// Action is the action to take.
+	Action game.Action
+	// Offset is when to take the action, from the start of the lap.
+	Offset time.Duration
+}
+
+// Replay is an agent that replays the actions of a recorded lap, ignoring the frames.
+type Replay struct {
+	// steps are the steps of the lap, in order.
+	steps []ReplayStep
+
+	// mu guards start.
+	mu sync.Mutex
+	// start is the time of the first observation.
+	start time.Time
+}
+
+// LoadReplay loads the replay of a recorded lap directory.
+// Only the frames where the action changes are kept as steps.
+func LoadReplay(dir string) (*Replay, error) {
+	frames, err := recording.ReadLap(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("no frames in %s", dir)
+	}
+
+	steps := make([]ReplayStep, 0, len(frames))
+	for i, frame := range frames {
+		if i > 0 && frame.Action == frames[i-1].Action {
+			continue
+		}
+
+		steps = append(steps, ReplayStep{
+			Action: frame.Action,
+			Offset: frame.Time.Sub(frames[0].Time),
+		})
+	}
+
+	// Keep the end of the lap as a final step.
+	last := frames[len(frames)-1]
+	if offset := last.Time.Sub(frames[0].Time); offset > steps[len(steps)-1].Offset {
+		steps = append(steps, ReplayStep{
+			Action: last.Action,
+			Offset: offset,
+		})
+	}
+
+	return NewReplay(steps), nil
+}
+
+// NewReplay creates a new replay agent from steps sorted by offset.
+func NewReplay(steps []ReplayStep) *Replay {
+	return &Replay{
+		steps: steps,
+	}
+}
+
+// Act returns the action of the step the observation falls into.
+// The replay starts on the first observation.
+func (r *Replay) Act(_ context.Context, obs Observation) (game.Action, error) {
+	r.mu.Lock()
+	if r.start.IsZero() {
+		r.start = obs.Time
+	}
+	elapsed := obs.Time.Sub(r.start)
+	r.mu.Unlock()
+
+	return r.actionAt(elapsed), nil
+}
+
+// Drive applies each step on its original offset from now, until the replay ends.
+func (r *Replay) Drive(ctx context.Context, apply func(game.Action) error) error {
+	if len(r.steps) == 0 {
+		return errors.New("replay has no steps")
+	}
+
+	start := time.Now()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for _, step := range r.steps {
+		timer.Reset(time.Until(start.Add(step.Offset)))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-timer.C:
+			if err := apply(step.Action); err != nil {
+				return fmt.Errorf("failed to apply step at %v: %w", step.Offset, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Duration returns the duration of the recorded lap.
+func (r *Replay) Duration() time.Duration {
+	if len(r.steps) == 0 {
+		return 0
+	}
+
+	return r.steps[len(r.steps)-1].Offset
+}
+
+// Reset restarts the replay on the next observation.
+func (r *Replay) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.start = time.Time{}
+}
+
+// actionAt returns the action held at the given offset.
+func (r *Replay) actionAt(offset time.Duration) game.Action {
+	action := game.Action{}
+	for _, step := range r.steps {
+		if step.Offset > offset {
+			break
+		}
+
+		action = step.Action
+	}
+
+	return action
+}
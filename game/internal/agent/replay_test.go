@@ -0,0 +1,157 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/game"
+	"github.com/nizarmah/stig/game/internal/recording"
+)
+
+// writeLap writes empty frames of a recorded lap, captured at the given offsets from start.
+func writeLap(t *testing.T, start time.Time, frames []ReplayStep) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for _, frame := range frames {
+		path := filepath.Join(dir, recording.FrameName(start.Add(frame.Offset), frame.Action))
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+
+	return dir
+}
+
+func TestLoadReplay(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+
+	neutral := game.Action{}
+	accelerate := game.Action{Throttle: game.ThrottleAccelerate}
+	left := game.Action{Throttle: game.ThrottleAccelerate, Steering: game.SteeringLeft}
+
+	tests := []struct {
+		name   string
+		frames []ReplayStep
+		want   []ReplayStep
+	}{
+		{
+			name: "changes",
+			frames: []ReplayStep{
+				{Action: neutral, Offset: 0},
+				{Action: accelerate, Offset: 100 * time.Millisecond},
+				{Action: accelerate, Offset: 200 * time.Millisecond},
+				{Action: left, Offset: 300 * time.Millisecond},
+				{Action: accelerate, Offset: 400 * time.Millisecond},
+			},
+			want: []ReplayStep{
+				{Action: neutral, Offset: 0},
+				{Action: accelerate, Offset: 100 * time.Millisecond},
+				{Action: left, Offset: 300 * time.Millisecond},
+				{Action: accelerate, Offset: 400 * time.Millisecond},
+			},
+		},
+		{
+			name: "held until the end",
+			frames: []ReplayStep{
+				{Action: accelerate, Offset: 0},
+				{Action: left, Offset: 50 * time.Millisecond},
+				{Action: left, Offset: 150 * time.Millisecond},
+				{Action: left, Offset: 2 * time.Second},
+			},
+			want: []ReplayStep{
+				{Action: accelerate, Offset: 0},
+				{Action: left, Offset: 50 * time.Millisecond},
+				{Action: left, Offset: 2 * time.Second},
+			},
+		},
+		{
+			name: "offset from the first frame",
+			frames: []ReplayStep{
+				{Action: accelerate, Offset: 5 * time.Second},
+				{Action: left, Offset: 5*time.Second + 250*time.Millisecond},
+			},
+			want: []ReplayStep{
+				{Action: accelerate, Offset: 0},
+				{Action: left, Offset: 250 * time.Millisecond},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replay, err := LoadReplay(writeLap(t, start, tt.frames))
+			if err != nil {
+				t.Fatalf("LoadReplay() error = %v", err)
+			}
+
+			if len(replay.steps) != len(tt.want) {
+				t.Fatalf("LoadReplay() steps = %+v, want %+v", replay.steps, tt.want)
+			}
+
+			for i, step := range replay.steps {
+				if step != tt.want[i] {
+					t.Errorf("step %d = %+v, want %+v", i, step, tt.want[i])
+				}
+			}
+
+			if got, want := replay.Duration(), tt.want[len(tt.want)-1].Offset; got != want {
+				t.Errorf("Duration() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestLoadReplayEmptyLap(t *testing.T) {
+	if _, err := LoadReplay(t.TempDir()); err == nil {
+		t.Error("LoadReplay() error = nil, want no frames reported")
+	}
+}
+
+func TestReplayAct(t *testing.T) {
+	accelerate := game.Action{Throttle: game.ThrottleAccelerate}
+	left := game.Action{Throttle: game.ThrottleAccelerate, Steering: game.SteeringLeft}
+
+	replay := NewReplay([]ReplayStep{
+		{Action: accelerate, Offset: 0},
+		{Action: left, Offset: 100 * time.Millisecond},
+	})
+
+	start := time.Unix(1_700_000_000, 0)
+
+	tests := []struct {
+		offset time.Duration
+		want   game.Action
+	}{
+		{offset: 0, want: accelerate},
+		{offset: 99 * time.Millisecond, want: accelerate},
+		{offset: 100 * time.Millisecond, want: left},
+		{offset: time.Second, want: left},
+	}
+
+	for _, tt := range tests {
+		action, err := replay.Act(context.Background(), Observation{Time: start.Add(tt.offset)})
+		if err != nil {
+			t.Fatalf("Act() error = %v", err)
+		}
+
+		if action != tt.want {
+			t.Errorf("Act() at %v = %+v, want %+v", tt.offset, action, tt.want)
+		}
+	}
+
+	// After a reset, the replay starts over on the next observation.
+	replay.Reset()
+
+	action, err := replay.Act(context.Background(), Observation{Time: start.Add(time.Minute)})
+	if err != nil {
+		t.Fatalf("Act() error = %v", err)
+	}
+
+	if action != accelerate {
+		t.Errorf("Act() after Reset() = %+v, want %+v", action, accelerate)
+	}
+}
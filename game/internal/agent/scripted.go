@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/game"
+)
+
+// Step is a single step of a script.
+type Step struct {
+	// Action is the action to take during the step.
+	Action game.Action
+	// Duration is how long the step lasts.
+	Duration time.Duration
+}
+
+// ScriptedConfiguration is the configuration for the scripted agent.
+type ScriptedConfiguration struct {
+	// Loop is whether to start over once the script ends.
+	Loop bool
+	// Steps are the steps of the script.
+	Steps []Step
+}
+
+// Scripted is an agent that follows a timed script, ignoring the frames.
+type Scripted struct {
+	// loop is whether to start over once the script ends.
+	loop bool
+	// steps are the steps of the script.
+	steps []Step
+
+	// mu guards start.
+	mu sync.Mutex
+	// start is the time of the first observation.
+	start time.Time
+}
+
+// NewScripted creates a new scripted agent.
+func NewScripted(cfg ScriptedConfiguration) *Scripted {
+	return &Scripted{
+		loop:  cfg.Loop,
+		steps: cfg.Steps,
+	}
+}
+
+// Act returns the action of the step the observation falls into.
+// The script starts on the first observation and holds its last step once it ends.
+func (s *Scripted) Act(_ context.Context, obs Observation) (game.Action, error) {
+	if len(s.steps) == 0 {
+		return game.Action{}, nil
+	}
+
+	s.mu.Lock()
+	if s.start.IsZero() {
+		s.start = obs.Time
+	}
+	elapsed := obs.Time.Sub(s.start)
+	s.mu.Unlock()
+
+	// Wrap around the script when looping.
+	if total := s.duration(); s.loop && total > 0 {
+		elapsed %= total
+	}
+
+	for _, step := range s.steps {
+		if elapsed < step.Duration {
+			return step.Action, nil
+		}
+
+		elapsed -= step.Duration
+	}
+
+	return s.steps[len(s.steps)-1].Action, nil
+}
+
+// Reset restarts the script on the next observation.
+func (s *Scripted) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.start = time.Time{}
+}
+
+// duration returns the total duration of the script.
+func (s *Scripted) duration() time.Duration {
+	var total time.Duration
+	for _, step := range s.steps {
+		total += step.Duration
+	}
+
+	return total
+}
@@ -0,0 +1,187 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/game"
+)
+
+// ShadowConfiguration is the configuration for the shadow agent.
+type ShadowConfiguration struct {
+	// Debug is whether to debug the shadow agent.
+	Debug bool
+	// Log is where each tick of the comparison is written, as JSON lines.
+	Log io.Writer
+	// Primary is the agent that drives the car.
+	Primary Agent
+	// Shadow is the agent evaluated alongside, without driving the car.
+	Shadow Agent
+}
+
+// Shadow is an agent that drives with its primary agent,
+// while comparing it with a shadow agent on the same observations.
+type Shadow struct {
+	debug   bool
+	primary Agent
+	shadow  Agent
+	// pending tracks the comparisons still waiting for the shadow agent.
+	pending sync.WaitGroup
+
+	// mu guards the fields below.
+	mu sync.Mutex
+	// log is where each tick of the comparison is written.
+	log *json.Encoder
+	// agreed is the number of ticks both agents agreed on.
+	agreed int
+	// compared is the number of ticks both agents answered.
+	compared int
+}
+
+// shadowTick is the comparison of both agents on a tick.
+type shadowTick struct {
+	// Agree is whether both agents took the same action.
+	Agree bool `json:"agree"`
+	// Primary is the action of the primary agent.
+	Primary *game.Action `json:"primary"`
+	// PrimaryError is the error of the primary agent, if any.
+	PrimaryError string `json:"primary_error,omitempty"`
+	// PrimaryLatency is the time the primary agent took to act.
+	PrimaryLatency time.Duration `json:"primary_latency_ns"`
+	// Shadow is the action of the shadow agent.
+	Shadow *game.Action `json:"shadow"`
+	// ShadowError is the error of the shadow agent, if any.
+	ShadowError string `json:"shadow_error,omitempty"`
+	// ShadowLatency is the time the shadow agent took to act.
+	ShadowLatency time.Duration `json:"shadow_latency_ns"`
+	// Tick is the index of the tick within the lap.
+	Tick int `json:"tick"`
+	// Time is when the frame was captured.
+	Time time.Time `json:"time"`
+}
+
+// actResult is the result of an agent acting on an observation.
+type actResult struct {
+	action  game.Action
+	err     error
+	latency time.Duration
+}
+
+// NewShadow creates a new shadow agent.
+func NewShadow(cfg ShadowConfiguration) *Shadow {
+	return &Shadow{
+		debug:   cfg.Debug,
+		log:     json.NewEncoder(cfg.Log),
+		primary: cfg.Primary,
+		shadow:  cfg.Shadow,
+	}
+}
+
+// Act returns the primary agent's action.
+// The shadow agent acts concurrently, and is compared once it answers,
+// so it never delays the primary agent.
+func (s *Shadow) Act(ctx context.Context, obs Observation) (game.Action, error) {
+	shadowed := make(chan actResult, 1)
+	go func() {
+		shadowed <- act(ctx, s.shadow, obs)
+	}()
+
+	primary := act(ctx, s.primary, obs)
+
+	s.pending.Add(1)
+	go s.compare(obs, primary, shadowed)
+
+	return primary.action, primary.err
+}
+
+// Agreement returns the number of ticks both agents agreed on,
+// out of the ticks both agents answered, since the last reset.
+// It waits for the comparisons still running, so it's called between laps.
+func (s *Shadow) Agreement() (agreed int, compared int) {
+	s.pending.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.agreed, s.compared
+}
+
+// Reset clears the agreement counts.
+// It waits for the comparisons still running, so they're not counted in the next lap.
+func (s *Shadow) Reset() {
+	s.pending.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.agreed = 0
+	s.compared = 0
+}
+
+// Info returns the description of the primary agent.
+func (s *Shadow) Info(ctx context.Context) (Info, error) {
+	return describe(ctx, s.primary)
+}
+
+// compare waits for the shadow agent and logs how it compares with the primary agent.
+func (s *Shadow) compare(obs Observation, primary actResult, shadowed <-chan actResult) {
+	defer s.pending.Done()
+
+	shadow := <-shadowed
+
+	tick := shadowTick{
+		PrimaryLatency: primary.latency,
+		ShadowLatency:  shadow.latency,
+		Tick:           obs.Tick,
+		Time:           obs.Time,
+	}
+
+	if primary.err != nil {
+		tick.PrimaryError = primary.err.Error()
+	} else {
+		tick.Primary = &primary.action
+	}
+
+	if shadow.err != nil {
+		tick.ShadowError = shadow.err.Error()
+	} else {
+		tick.Shadow = &shadow.action
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if primary.err == nil && shadow.err == nil {
+		tick.Agree = primary.action == shadow.action
+
+		s.compared++
+		if tick.Agree {
+			s.agreed++
+		}
+	}
+
+	if err := s.log.Encode(tick); err != nil {
+		log.Println(fmt.Sprintf("failed to log shadow tick %d: %v", obs.Tick, err))
+	}
+
+	if s.debug && !tick.Agree {
+		log.Println(fmt.Sprintf("shadow disagreed on tick %d: primary: %+v, shadow: %+v", obs.Tick, tick.Primary, tick.Shadow))
+	}
+}
+
+// act asks an agent to act on an observation and times it.
+func act(ctx context.Context, a Agent, obs Observation) actResult {
+	start := time.Now()
+	action, err := a.Act(ctx, obs)
+
+	return actResult{
+		action:  action,
+		err:     err,
+		latency: time.Since(start),
+	}
+}
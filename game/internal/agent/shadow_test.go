@@ -0,0 +1,48 @@
+package agent
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/game"
+)
+
+func TestShadowCountsLateComparisonsInTheirLap(t *testing.T) {
+	accelerate := game.Action{Throttle: game.ThrottleAccelerate}
+
+	shadow := NewShadow(ShadowConfiguration{
+		Log: io.Discard,
+		Primary: Func(func(context.Context, Observation) (game.Action, error) {
+			return accelerate, nil
+		}),
+		// The shadow answers well after the primary agent.
+		Shadow: Func(func(context.Context, Observation) (game.Action, error) {
+			time.Sleep(50 * time.Millisecond)
+			return accelerate, nil
+		}),
+	})
+
+	for tick := range 3 {
+		if _, err := shadow.Act(context.Background(), Observation{Tick: tick}); err != nil {
+			t.Fatalf("Act() error = %v", err)
+		}
+	}
+
+	// The lap ends before the shadow answered its last ticks.
+	if agreed, compared := shadow.Agreement(); agreed != 3 || compared != 3 {
+		t.Errorf("Agreement() = %d/%d, want 3/3", agreed, compared)
+	}
+
+	// A tick still running when the next lap starts isn't counted in it.
+	if _, err := shadow.Act(context.Background(), Observation{}); err != nil {
+		t.Fatalf("Act() error = %v", err)
+	}
+
+	shadow.Reset()
+
+	if agreed, compared := shadow.Agreement(); agreed != 0 || compared != 0 {
+		t.Errorf("Agreement() after Reset() = %d/%d, want 0/0", agreed, compared)
+	}
+}
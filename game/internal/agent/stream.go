@@ -0,0 +1,281 @@
+package agent
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/nizarmah/stig/game/internal/game"
+	"github.com/nizarmah/stig/game/internal/metrics"
+)
+
+var (
+	// errStreamClosed is returned to pending frames when the stream is closed.
+	errStreamClosed = errors.New("stream closed")
+	// errSuperseded is returned when a newer frame was answered first.
+	errSuperseded = errors.New("frame superseded by a newer one")
+)
+
+// streamResponse is the response of the agent to a streamed frame.
+type streamResponse struct {
+	game.Action
+	// Seq is the sequence number of the frame.
+	Seq uint64 `json:"seq"`
+}
+
+// streamResult is the result delivered to a pending frame.
+type streamResult struct {
+	action game.Action
+	err    error
+}
+
+// Stream is the agent that plays the game over a persistent WebSocket.
+// Frames are sent as binary messages prefixed with a big-endian sequence number,
+// and the agent answers with JSON actions tagged with the same sequence number.
+type Stream struct {
+	apiURL  string
+	debug   bool
+	metrics *metrics.Metrics
+	timeout time.Duration
+
+	// writeMu serializes writes to the connection.
+	writeMu sync.Mutex
+
+	// mu guards the fields below.
+	mu sync.Mutex
+	// conn is the open connection, if any.
+	conn *websocket.Conn
+	// seq is the sequence number of the last frame sent.
+	seq uint64
+	// answered is the sequence number of the last frame answered.
+	answered uint64
+	// pending are the frames waiting for an answer.
+	pending map[uint64]chan streamResult
+	// dropped is the number of late answers dropped.
+	dropped int
+}
+
+// NewStream creates a new stream agent.
+// The connection is opened on the first frame and reopened after failures.
+func NewStream(cfg ClientConfiguration) *Stream {
+	return &Stream{
+		apiURL:  cfg.APIURL,
+		debug:   cfg.Debug,
+		metrics: cfg.Metrics,
+		timeout: cfg.Timeout,
+		pending: make(map[uint64]chan streamResult),
+	}
+}
+
+// Act returns the action to take on the given observation.
+func (s *Stream) Act(ctx context.Context, obs Observation) (game.Action, error) {
+	start := time.Now()
+	action, err := s.act(ctx, obs)
+	observe(s.metrics, time.Since(start), err)
+
+	return action, err
+}
+
+// act streams the observation to the agent and waits for its answer.
+func (s *Stream) act(ctx context.Context, obs Observation) (game.Action, error) {
+	// Bound the frame by the agent timeout.
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	conn, err := s.connect(ctx)
+	if err != nil {
+		return game.Action{}, err
+	}
+
+	// Register the frame before sending it, so the answer can't be missed.
+	result := make(chan streamResult, 1)
+
+	s.mu.Lock()
+	s.seq++
+	seq := s.seq
+	s.pending[seq] = result
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, seq)
+		s.mu.Unlock()
+	}()
+
+	// Send the frame.
+	msg := make([]byte, 8+len(obs.Frame))
+	binary.BigEndian.PutUint64(msg, seq)
+	copy(msg[8:], obs.Frame)
+
+	s.writeMu.Lock()
+	err = send(ctx, conn, msg)
+	s.writeMu.Unlock()
+
+	if err != nil {
+		if s.debug {
+			log.Println(fmt.Sprintf("agent failed to send frame %d: %v", seq, err))
+		}
+
+		s.disconnect(conn, err)
+		return game.Action{}, fmt.Errorf("failed to send frame: %w", err)
+	}
+
+	// Wait for the answer.
+	select {
+	case <-ctx.Done():
+		return game.Action{}, fmt.Errorf("failed to receive action for frame %d: %w", seq, ctx.Err())
+
+	case res := <-result:
+		if res.err != nil {
+			return game.Action{}, fmt.Errorf("failed to receive action for frame %d: %w", seq, res.err)
+		}
+
+		if s.debug {
+			log.Println(fmt.Sprintf("agent action for frame %d: %+v", seq, res.action))
+		}
+
+		return res.action, nil
+	}
+}
+
+// send writes a message to the connection, bounded by the deadline of the context.
+// Without one, the deadline left by a previous message is cleared.
+func send(ctx context.Context, conn *websocket.Conn, msg []byte) error {
+	// The zero time means no deadline.
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetWriteDeadline(deadline); err != nil {
+		return fmt.Errorf("failed to set write deadline: %w", err)
+	}
+
+	return conn.WriteMessage(websocket.BinaryMessage, msg)
+}
+
+// Dropped returns the number of late answers dropped so far.
+func (s *Stream) Dropped() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.dropped
+}
+
+// Close closes the connection.
+func (s *Stream) Close() {
+	s.mu.Lock()
+	conn := s.conn
+	s.mu.Unlock()
+
+	if conn != nil {
+		s.disconnect(conn, errStreamClosed)
+	}
+}
+
+// connect returns the open connection, opening one if needed.
+func (s *Stream) connect(ctx context.Context) (*websocket.Conn, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != nil {
+		return s.conn, nil
+	}
+
+	url := fmt.Sprintf("%s/stream", s.apiURL)
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, nil)
+	if err != nil {
+		if s.debug {
+			log.Println(fmt.Sprintf("agent failed to connect: %v", err))
+		}
+
+		return nil, fmt.Errorf("failed to connect to %s: %w", url, err)
+	}
+
+	if s.debug {
+		log.Println(fmt.Sprintf("agent connected to %s", url))
+	}
+
+	s.conn = conn
+	go s.read(conn)
+
+	return conn, nil
+}
+
+// disconnect forgets the connection and fails the frames waiting on it.
+func (s *Stream) disconnect(conn *websocket.Conn, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != conn {
+		return
+	}
+
+	s.conn = nil
+	conn.Close()
+
+	for seq, result := range s.pending {
+		result <- streamResult{err: err}
+		delete(s.pending, seq)
+	}
+}
+
+// read delivers the answers of the agent to the pending frames.
+func (s *Stream) read(conn *websocket.Conn) {
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if s.debug {
+				log.Println(fmt.Sprintf("agent connection closed: %v", err))
+			}
+
+			s.disconnect(conn, err)
+			return
+		}
+
+		resp := streamResponse{}
+		if err := json.Unmarshal(data, &resp); err != nil {
+			if s.debug {
+				log.Println(fmt.Sprintf("agent failed to decode response: %v", err))
+			}
+
+			continue
+		}
+
+		s.deliver(resp)
+	}
+}
+
+// deliver hands an answer to its frame, dropping answers to superseded frames.
+func (s *Stream) deliver(resp streamResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, ok := s.pending[resp.Seq]
+
+	// Drop answers that nobody waits for, or that a newer answer overtook.
+	if !ok || resp.Seq <= s.answered {
+		s.dropped++
+		if s.debug {
+			log.Println(fmt.Sprintf("agent dropped late action for frame %d", resp.Seq))
+		}
+
+		if ok {
+			result <- streamResult{err: errSuperseded}
+			delete(s.pending, resp.Seq)
+		}
+
+		return
+	}
+
+	s.answered = resp.Seq
+	result <- streamResult{action: resp.Action}
+	delete(s.pending, resp.Seq)
+}
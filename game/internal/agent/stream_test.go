@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"context"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/nizarmah/stig/game/internal/game"
+)
+
+// newStreamServer serves a stream agent that always accelerates,
+// and reports on closed when a connection closes.
+func newStreamServer(t *testing.T) (url string, closed <-chan struct{}) {
+	t.Helper()
+
+	disconnected := make(chan struct{}, 1)
+	upgrader := websocket.Upgrader{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				disconnected <- struct{}{}
+				return
+			}
+
+			if err := conn.WriteJSON(streamResponse{
+				Action: game.Action{Throttle: game.ThrottleAccelerate},
+				Seq:    binary.BigEndian.Uint64(data),
+			}); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return "ws" + strings.TrimPrefix(server.URL, "http"), disconnected
+}
+
+func TestStreamClearsWriteDeadline(t *testing.T) {
+	url, _ := newStreamServer(t)
+	stream := NewStream(ClientConfiguration{APIURL: url})
+	defer stream.Close()
+
+	// The first frame leaves a deadline on the connection.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if _, err := stream.Act(ctx, Observation{}); err != nil {
+		t.Fatalf("Act() with a deadline error = %v", err)
+	}
+
+	// A frame without a deadline is sent after it passed.
+	time.Sleep(150 * time.Millisecond)
+
+	if _, err := stream.Act(context.Background(), Observation{}); err != nil {
+		t.Fatalf("Act() after the previous deadline passed error = %v", err)
+	}
+}
+
+func TestCloseClosesEnsembleStreams(t *testing.T) {
+	url, closed := newStreamServer(t)
+
+	member := NewStream(ClientConfiguration{APIURL: url})
+	ensemble, err := NewEnsemble(EnsembleConfiguration{
+		Members:  []EnsembleMember{{Agent: member, Name: "stream"}},
+		Strategy: EnsembleVote,
+	})
+	if err != nil {
+		t.Fatalf("NewEnsemble() error = %v", err)
+	}
+
+	if _, err := ensemble.Act(context.Background(), Observation{}); err != nil {
+		t.Fatalf("Act() error = %v", err)
+	}
+
+	Close(ensemble)
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close() left the stream connection open")
+	}
+}
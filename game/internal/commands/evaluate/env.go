@@ -0,0 +1,42 @@
+package evaluate
+
+import (
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/agent"
+	"github.com/nizarmah/stig/game/internal/commands"
+)
+
+// Env represents the configuration of the eval command.
+type Env struct {
+	commands.Game
+
+	// AgentDebug is whether to debug the agent client.
+	AgentDebug bool `env:"AGENT_DEBUG" default:"false" help:"whether to debug the agent client"`
+	// AgentFallback is the policy to apply when the agent fails to act in time.
+	AgentFallback agent.FallbackPolicy `env:"AGENT_FALLBACK" default:"neutral" enum:"hold,neutral,brake" help:"policy when the agent fails to act in time"`
+	// AgentURL is the URL of the agent to evaluate (http:// to post frames, ws:// to stream them).
+	AgentURL string `env:"AGENT_URL" default:"http://localhost:8080" required:"true" url:"http,https,ws,wss" help:"URL of the agent to evaluate (http:// to post frames, ws:// to stream them)"`
+	// AgentTimeout is the timeout for the agent to act (milliseconds).
+	AgentTimeout time.Duration `env:"AGENT_TIMEOUT_MS" default:"200" unit:"ms" replaces:"AGENT_TIMEOUT" replacedunit:"s" min:"1" help:"timeout for the agent to act, in milliseconds"`
+	// EvalMaxAgentP90Latency is the highest p90 agent latency to pass (milliseconds, 0 disables it).
+	EvalMaxAgentP90Latency time.Duration `env:"EVAL_MAX_AGENT_P90_LATENCY" default:"0" unit:"ms" min:"0" help:"highest p90 agent latency to pass, in milliseconds (0 disables it)"`
+	// EvalMaxMeanLapTime is the highest mean lap time to pass (milliseconds, 0 disables it).
+	EvalMaxMeanLapTime time.Duration `env:"EVAL_MAX_MEAN_LAP_TIME" default:"0" unit:"ms" min:"0" help:"highest mean lap time to pass, in milliseconds (0 disables it)"`
+	// EvalMaxP90LapTime is the highest p90 lap time to pass (milliseconds, 0 disables it).
+	EvalMaxP90LapTime time.Duration `env:"EVAL_MAX_P90_LAP_TIME" default:"0" unit:"ms" min:"0" help:"highest p90 lap time to pass, in milliseconds (0 disables it)"`
+	// EvalMinCompletionRate is the lowest percentage of finished laps to pass (0 disables it).
+	EvalMinCompletionRate int `env:"EVAL_MIN_COMPLETION_RATE" default:"0" min:"0" max:"100" help:"lowest percentage of finished laps to pass (0 disables it)"`
+	// EvalReportFile is the file to write the JSON report to.
+	EvalReportFile string `env:"EVAL_REPORT_FILE" default:"assets/eval.json" help:"file to write the JSON report to"`
+	// LapTimeout is the timeout for a single lap (seconds).
+	LapTimeout time.Duration `env:"LAP_TIMEOUT" default:"120" unit:"s" min:"1" help:"timeout for a single lap, in seconds"`
+	// LapsNum is the number of laps to evaluate the agent on.
+	LapsNum int `env:"LAPS_NUM" default:"10" min:"1" help:"number of laps to evaluate the agent on"`
+	// PipelineDepth is the number of frames inferred concurrently (0 disables pipelining).
+	PipelineDepth int `env:"PIPELINE_DEPTH" default:"0" min:"0" help:"number of frames inferred concurrently (0 disables pipelining)"`
+	// ScreenDebug is whether to debug the screen package.
+	ScreenDebug bool `env:"SCREEN_DEBUG" default:"false" help:"whether to debug the screen"`
+	// ScreenResolution is the resolution of the screen.
+	ScreenResolution int `env:"SCREEN_RESOLUTION" default:"100" min:"0" max:"100" help:"resolution of the screen"`
+}
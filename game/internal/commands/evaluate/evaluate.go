@@ -0,0 +1,165 @@
+// Package evaluate scores an agent over a number of laps and gates it on thresholds.
+package evaluate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/nizarmah/stig/game/internal/agent"
+	"github.com/nizarmah/stig/game/internal/controller"
+	"github.com/nizarmah/stig/game/internal/driver"
+	"github.com/nizarmah/stig/game/internal/eval"
+	"github.com/nizarmah/stig/game/internal/game"
+	"github.com/nizarmah/stig/game/internal/screen"
+)
+
+// errInterrupted is returned when the evaluation is stopped before all its laps were played.
+var errInterrupted = errors.New("interrupted")
+
+// Run evaluates the agent, and exits with an error when it fails the thresholds.
+// When stopped early, the laps played so far are still reported, and the evaluation fails.
+// When the game or the agent can't be set up, an empty report is written, and the evaluation fails.
+func Run(ctx context.Context, env *Env) {
+	// Evaluate the agent.
+	report, err := evaluate(ctx, env)
+	switch {
+	case errors.Is(err, errInterrupted):
+		log.Println(fmt.Sprintf("evaluation %v", err))
+		report.Interrupted = true
+
+	case err != nil:
+		log.Println(fmt.Sprintf("failed to evaluate agent: %v", err))
+		report.Error = err.Error()
+	}
+
+	report.Check(eval.Thresholds{
+		MaxAgentP90Latency: env.EvalMaxAgentP90Latency,
+		MaxMeanLapTime:     env.EvalMaxMeanLapTime,
+		MaxP90LapTime:      env.EvalMaxP90LapTime,
+		MinCompletionRate:  float64(env.EvalMinCompletionRate),
+	})
+
+	// Write the report.
+	if err := writeReport(env.EvalReportFile, report); err != nil {
+		log.Fatalf("failed to write report: %v", err)
+	}
+
+	if err := report.WriteTable(os.Stdout); err != nil {
+		log.Fatalf("failed to print report: %v", err)
+	}
+
+	if !report.Passed {
+		os.Exit(1)
+	}
+}
+
+// evaluate plays the laps with the agent and reports on them.
+// It returns an empty report when the game or the agent can't be set up.
+func evaluate(ctx context.Context, env *Env) (eval.Report, error) {
+	// Create the game client.
+	gameClient, err := game.NewClient(
+		ctx,
+		game.ClientConfig{
+			BrowserBin:      env.BrowserBin,
+			BrowserHeadless: env.BrowserHeadless,
+			BrowserWSURL:    env.BrowserWSURL,
+			Debug:           env.GameDebug,
+			FPS:             env.FramesPerSecond,
+			GameURL:         env.GameURL,
+			WindowHeight:    env.WindowHeight,
+			WindowWidth:     env.WindowWidth,
+		},
+		env.GameTimeout,
+	)
+	if err != nil {
+		return eval.NewReport(agent.Info{}, nil), fmt.Errorf("failed to create game client: %w", err)
+	}
+	defer gameClient.Close()
+
+	// Create the agent.
+	baseAgent, err := agent.New(agent.ClientConfiguration{
+		APIURL:  env.AgentURL,
+		Debug:   env.AgentDebug,
+		Timeout: env.AgentTimeout,
+	})
+	if err != nil {
+		return eval.NewReport(agent.Info{}, nil), fmt.Errorf("failed to create agent: %w", err)
+	}
+	defer agent.Close(baseAgent)
+
+	agentInfo, err := agent.Handshake(ctx, baseAgent, agent.Frame{Height: env.WindowHeight, Width: env.WindowWidth})
+	if err != nil {
+		return eval.NewReport(agent.Info{}, nil), fmt.Errorf("failed to handshake with agent: %w", err)
+	}
+
+	log.Println(fmt.Sprintf("agent: %s", agentInfo))
+
+	agentClient := agent.NewFallback(agent.FallbackConfiguration{
+		Agent:  baseAgent,
+		Debug:  env.AgentDebug,
+		Policy: env.AgentFallback,
+	})
+
+	driverConfig := driver.Configuration{
+		Agent:      agentClient,
+		Controller: controller.NewClient(gameClient.Page),
+		Debug:      env.AgentDebug,
+		Depth:      env.PipelineDepth,
+		Screen: screen.NewClient(screen.ClientConfiguration{
+			Debug:        env.ScreenDebug,
+			Page:         gameClient.Page,
+			Resolution:   env.ScreenResolution,
+			WindowHeight: env.WindowHeight,
+			WindowWidth:  env.WindowWidth,
+		}),
+	}
+
+	// Play the laps.
+	laps := make([]eval.Lap, 0, env.LapsNum)
+	for lap := range env.LapsNum {
+		if ctx.Err() != nil {
+			return eval.NewReport(agentInfo, laps), interrupted(ctx, lap, env.LapsNum)
+		}
+
+		played, err := eval.PlayLap(ctx, gameClient, driverConfig, agentClient, env.LapTimeout)
+
+		// A lap cut short by the interruption says nothing about the agent.
+		if ctx.Err() != nil {
+			return eval.NewReport(agentInfo, laps), interrupted(ctx, lap, env.LapsNum)
+		}
+
+		if err != nil {
+			log.Println(fmt.Sprintf("failed to play lap %d: %v", lap, err))
+		}
+
+		played.Result.Lap = lap
+		log.Println(fmt.Sprintf("lap %d: %s, time: %s", lap, played.Result.Status, played.Result.LapTime))
+
+		laps = append(laps, played)
+	}
+
+	return eval.NewReport(agentInfo, laps), nil
+}
+
+// interrupted returns the error of an evaluation stopped after the given number of laps.
+func interrupted(ctx context.Context, played int, laps int) error {
+	return fmt.Errorf("%w after %d of %d laps: %w", errInterrupted, played, laps, ctx.Err())
+}
+
+// writeReport writes the report as JSON to a file.
+func writeReport(path string, report eval.Report) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write report file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+// Package commands holds the settings shared by the commands of the application.
+package commands
+
+import (
+	"time"
+)
+
+// Game is the configuration of the browser and the game, shared by the commands.
+type Game struct {
+	// BrowserBin is the path of the browser to launch (empty looks up a local one).
+	BrowserBin string `env:"BROWSER_BIN" help:"path of the browser to launch (empty looks up a local one)"`
+	// BrowserHeadless is whether to launch the browser without a window.
+	BrowserHeadless bool `env:"BROWSER_HEADLESS" default:"false" help:"whether to launch the browser without a window"`
+	// BrowserWSURL is the URL of the browser to control (empty launches one).
+	BrowserWSURL string `env:"BROWSER_WS_URL" url:"ws,wss" help:"URL of the browser to control (empty launches one)"`
+	// FramesPerSecond is the frames per second of the game loop.
+	FramesPerSecond int `env:"FRAMES_PER_SECOND" default:"10" min:"1" help:"frames per second of the game loop"`
+	// GameDebug is whether to debug the game client.
+	GameDebug bool `env:"GAME_DEBUG" default:"false" help:"whether to debug the game client"`
+	// GameTimeout is the timeout for starting the game client (seconds).
+	GameTimeout time.Duration `env:"GAME_TIMEOUT" default:"10" unit:"s" min:"1" help:"timeout for starting the game client, in seconds"`
+	// GameURL is the URL of the game to play.
+	GameURL string `env:"GAME_URL" default:"https://www.shopify.com/ca/editions/summer2025/drive" required:"true" url:"http,https" help:"URL of the game to play"`
+	// WindowHeight is the height of the window.
+	WindowHeight int `env:"WINDOW_HEIGHT" default:"600" min:"1" help:"height of the window"`
+	// WindowWidth is the width of the window.
+	WindowWidth int `env:"WINDOW_WIDTH" default:"960" min:"1" help:"width of the window"`
+}
@@ -0,0 +1,7 @@
+package mock
+
+// Env represents the configuration of the mock game command.
+type Env struct {
+	// MockGameAddr is the address to serve the mock game on.
+	MockGameAddr string `env:"MOCK_GAME_ADDR" default:"localhost:8000" required:"true" help:"address to serve the mock game on"`
+}
@@ -0,0 +1,30 @@
+// Package mock serves a stand-in for the racing game, to play and record offline.
+package mock
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/nizarmah/stig/game/internal/mockgame"
+)
+
+// Run serves the mock game until the context is done.
+func Run(ctx context.Context, env *Env) {
+	// Create the server.
+	server := &http.Server{
+		Addr:    env.MockGameAddr,
+		Handler: mockgame.Handler(),
+	}
+
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	log.Printf("serving mock game on http://%s", env.MockGameAddr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("failed to serve mock game: %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package play
+
+import (
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/agent"
+	"github.com/nizarmah/stig/game/internal/commands"
+)
+
+// Env represents the configuration of the play command.
+type Env struct {
+	commands.Game
+
+	// AgentDebug is whether to debug the agent client.
+	AgentDebug bool `env:"AGENT_DEBUG" default:"false" help:"whether to debug the agent client"`
+	// AgentEnsembleStrategy is how the actions are combined when there are several agent URLs.
+	AgentEnsembleStrategy agent.EnsembleStrategy `env:"AGENT_ENSEMBLE_STRATEGY" default:"vote" enum:"vote,priority,first" help:"how the actions of several agent URLs are combined"`
+	// AgentFallback is the policy to apply when the agent fails to act in time.
+	AgentFallback agent.FallbackPolicy `env:"AGENT_FALLBACK" default:"neutral" enum:"hold,neutral,brake" help:"policy when the agent fails to act in time"`
+	// AgentURLs are the URLs of the agents to use (http:// to post frames, ws:// to stream them).
+	// Several URLs make an ensemble of agents.
+	AgentURLs []string `env:"AGENT_URL" default:"http://localhost:8080" required:"true" url:"http,https,ws,wss" help:"URL of the agent (http:// to post frames, ws:// to stream them), comma-separated for an ensemble"`
+	// AgentTimeout is the timeout for the agent to act (milliseconds).
+	AgentTimeout time.Duration `env:"AGENT_TIMEOUT_MS" default:"200" unit:"ms" replaces:"AGENT_TIMEOUT" replacedunit:"s" min:"1" help:"timeout for the agent to act, in milliseconds"`
+	// BrowsersNum is the number of browsers to spread the workers across.
+	BrowsersNum int `env:"BROWSERS_NUM" default:"1" min:"1" help:"number of browsers to spread the workers across"`
+	// ControllerDebug is whether to debug the controller package.
+	ControllerDebug bool `env:"CONTROLLER_DEBUG" default:"false" help:"whether to debug the controller"`
+	// DashboardAddr is the address to serve the live dashboard on (empty disables it).
+	DashboardAddr string `env:"DASHBOARD_ADDR" help:"address to serve the live dashboard on (empty disables it)"`
+	// HumanOverride is whether a human can take over by pressing keys in the browser.
+	// The agent presses the arrow keys, so the human takes over with WASD and Space.
+	HumanOverride bool `env:"HUMAN_OVERRIDE" default:"false" help:"whether a human can take over with WASD and Space in the browser"`
+	// LapTimeout is the timeout for a single lap (seconds).
+	LapTimeout time.Duration `env:"LAP_TIMEOUT" default:"120" unit:"s" min:"1" help:"timeout for a single lap, in seconds"`
+	// MetricsAddr is the address to serve the metrics on (empty disables it).
+	MetricsAddr string `env:"METRICS_ADDR" help:"address to serve the metrics on (empty disables it)"`
+	// PipelineDepth is the number of frames inferred concurrently (0 disables pipelining).
+	PipelineDepth int `env:"PIPELINE_DEPTH" default:"0" min:"0" help:"number of frames inferred concurrently (0 disables pipelining)"`
+	// RecordingsDir is the directory to output the overridden ticks.
+	RecordingsDir string `env:"RECORDINGS_DIR" default:"assets/recordings/" help:"directory to output the overridden ticks"`
+	// ScreenDebug is whether to debug the screen package.
+	ScreenDebug bool `env:"SCREEN_DEBUG" default:"false" help:"whether to debug the screen"`
+	// ScreenResolution is the resolution of the screen.
+	ScreenResolution int `env:"SCREEN_RESOLUTION" default:"100" min:"0" max:"100" help:"resolution of the screen"`
+	// ShadowAgentURL is the URL of the agent to evaluate alongside, without driving (empty disables it).
+	ShadowAgentURL string `env:"SHADOW_AGENT_URL" url:"http,https,ws,wss" help:"URL of the agent to evaluate alongside, without driving (empty disables it)"`
+	// ShadowLogFile is the file to log the comparison with the shadow agent to.
+	ShadowLogFile string `env:"SHADOW_LOG_FILE" default:"assets/shadow.jsonl" help:"file to log the comparison with the shadow agent to"`
+	// WorkersNum is the number of game pages playing laps in parallel.
+	WorkersNum int `env:"WORKERS_NUM" default:"1" min:"1" help:"number of game pages playing laps in parallel"`
+}
@@ -0,0 +1,164 @@
+package play
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/nizarmah/stig/game/internal/agent"
+	"github.com/nizarmah/stig/game/internal/game"
+	"github.com/nizarmah/stig/game/internal/recording"
+)
+
+const (
+	// overridesFile is the file listing the overridden ticks of a lap.
+	overridesFile = "overrides.jsonl"
+	// overrideQueueSize is the number of overridden frames that can wait to be written.
+	overrideQueueSize = 64
+)
+
+// override is an overridden tick.
+type override struct {
+	// Agent is the action the agent proposed.
+	Agent game.Action `json:"agent"`
+	// Frame is the file name of the frame.
+	Frame string `json:"frame"`
+	// Human is the action taken, with the axes the human didn't hold left to the agent.
+	Human game.Action `json:"human"`
+	// Tick is the index of the tick within the lap.
+	Tick int `json:"tick"`
+}
+
+// overrideRecorder saves the overridden ticks in the same layout as the recorder,
+// labeled with the action taken, so they can be used as corrective data.
+// The frames are written in the background, and the overrides are buffered until the lap ends,
+// so the disk stays off the tick path.
+type overrideRecorder struct {
+	// dir is the directory of the laps.
+	dir string
+
+	// mu guards the fields below.
+	mu sync.Mutex
+	// lap is the current lap.
+	lap int
+	// writer writes the frames of the current lap, once the human took over.
+	writer *recording.Writer
+	// overridesFile is the overrides file of the current lap, once the human took over.
+	overridesFile *os.File
+	// overrides buffers the writes to the overrides file.
+	overrides *bufio.Writer
+}
+
+// newOverrideRecorder creates a new override recorder saving laps to the given directory.
+func newOverrideRecorder(dir string) (*overrideRecorder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create recordings directory: %w", err)
+	}
+
+	return &overrideRecorder{
+		dir: dir,
+	}, nil
+}
+
+// startLap saves the next overridden ticks to the given lap.
+func (r *overrideRecorder) startLap(lap int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lap = lap
+}
+
+// endLap writes the overridden ticks of the current lap still pending.
+// It returns the number of frames that were dropped or failed to be written, along with the last error.
+func (r *overrideRecorder) endLap() (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.writer == nil {
+		return 0, nil
+	}
+
+	stats, err := r.writer.Close()
+	lost := stats.Dropped + stats.Errors
+
+	if flushErr := r.overrides.Flush(); flushErr != nil {
+		err = fmt.Errorf("failed to write overrides: %w", flushErr)
+	}
+
+	if closeErr := r.overridesFile.Close(); closeErr != nil && err == nil {
+		err = fmt.Errorf("failed to close overrides file: %w", closeErr)
+	}
+
+	r.writer = nil
+	r.overridesFile = nil
+	r.overrides = nil
+
+	return lost, err
+}
+
+// record saves an overridden tick.
+// The lap directory is only created once the human takes over.
+func (r *overrideRecorder) record(obs agent.Observation, action, proposed game.Action) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.writer == nil {
+		if err := r.openLap(); err != nil {
+			return err
+		}
+	}
+
+	// Save the frame, labeled with the action taken.
+	frameName := recording.FrameName(obs.Time, action)
+	if !r.writer.Write(obs.Frame, recording.FrameEntry{
+		Action: action,
+		File:   frameName,
+		Tick:   obs.Tick,
+		Time:   obs.Time,
+		Type:   recording.EntryFrame,
+	}) {
+		return fmt.Errorf("dropped frame %s: recording queue is full", frameName)
+	}
+
+	// Save the agent's proposal next to it.
+	line, err := json.Marshal(override{
+		Agent: proposed,
+		Frame: frameName,
+		Human: action,
+		Tick:  obs.Tick,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal override: %w", err)
+	}
+
+	if _, err := r.overrides.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write override: %w", err)
+	}
+
+	return nil
+}
+
+// openLap creates the directory of the current lap, and opens its writer and overrides file.
+func (r *overrideRecorder) openLap() error {
+	lapDir := filepath.Join(r.dir, recording.LapName(r.lap))
+	if err := os.MkdirAll(lapDir, 0755); err != nil {
+		return fmt.Errorf("failed to create lap directory: %w", err)
+	}
+
+	f, err := os.OpenFile(filepath.Join(lapDir, overridesFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open overrides file: %w", err)
+	}
+
+	r.overridesFile = f
+	r.overrides = bufio.NewWriter(f)
+	r.writer = recording.NewWriter(recording.WriterConfiguration{
+		Dir:       lapDir,
+		QueueSize: overrideQueueSize,
+	})
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package play
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/agent"
+	"github.com/nizarmah/stig/game/internal/game"
+	"github.com/nizarmah/stig/game/internal/recording"
+)
+
+func TestOverrideRecorder(t *testing.T) {
+	dir := t.TempDir()
+
+	recorder, err := newOverrideRecorder(dir)
+	if err != nil {
+		t.Fatalf("newOverrideRecorder() error = %v", err)
+	}
+
+	brake := game.Action{Throttle: game.ThrottleBrake}
+	accelerate := game.Action{Throttle: game.ThrottleAccelerate}
+	start := time.Now()
+
+	// The first lap isn't overridden, so it's left out.
+	recorder.startLap(0)
+	if lost, err := recorder.endLap(); lost != 0 || err != nil {
+		t.Fatalf("endLap() of a lap without overrides = %d, %v", lost, err)
+	}
+
+	recorder.startLap(1)
+	for tick := range 3 {
+		obs := agent.Observation{
+			Frame: []byte{byte(tick)},
+			Tick:  tick,
+			Time:  start.Add(time.Duration(tick) * 100 * time.Millisecond),
+		}
+
+		if err := recorder.record(obs, brake, accelerate); err != nil {
+			t.Fatalf("record() error = %v", err)
+		}
+	}
+
+	if lost, err := recorder.endLap(); lost != 0 || err != nil {
+		t.Fatalf("endLap() = %d, %v", lost, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, recording.LapName(0))); !os.IsNotExist(err) {
+		t.Errorf("lap without overrides was saved: %v", err)
+	}
+
+	lapDir := filepath.Join(dir, recording.LapName(1))
+
+	frames, err := recording.ReadLap(lapDir)
+	if err != nil {
+		t.Fatalf("ReadLap() error = %v", err)
+	}
+
+	if len(frames) != 3 {
+		t.Fatalf("lap has %d frames, want 3", len(frames))
+	}
+
+	for i, frame := range frames {
+		if frame.Action != brake {
+			t.Errorf("frame %d is labeled %+v, want the action taken %+v", i, frame.Action, brake)
+		}
+	}
+
+	f, err := os.Open(filepath.Join(lapDir, overridesFile))
+	if err != nil {
+		t.Fatalf("failed to open overrides: %v", err)
+	}
+	defer f.Close()
+
+	lines := 0
+	for scanner := bufio.NewScanner(f); scanner.Scan(); lines++ {
+		o := override{}
+		if err := json.Unmarshal(scanner.Bytes(), &o); err != nil {
+			t.Fatalf("failed to unmarshal override: %v", err)
+		}
+
+		if o.Tick != lines || o.Agent != accelerate || o.Human != brake {
+			t.Errorf("override %d = %+v", lines, o)
+		}
+
+		if _, err := os.Stat(filepath.Join(lapDir, o.Frame)); err != nil {
+			t.Errorf("override %d points to a missing frame: %v", lines, err)
+		}
+	}
+
+	if lines != 3 {
+		t.Errorf("overrides has %d lines, want 3", lines)
+	}
+}
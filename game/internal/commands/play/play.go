@@ -0,0 +1,187 @@
+// Package play plays the game with an agent.
+package play
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/agent"
+	"github.com/nizarmah/stig/game/internal/dashboard"
+	"github.com/nizarmah/stig/game/internal/driver"
+	"github.com/nizarmah/stig/game/internal/game"
+	"github.com/nizarmah/stig/game/internal/metrics"
+	"github.com/nizarmah/stig/game/internal/pool"
+	"github.com/nizarmah/stig/game/internal/recording"
+)
+
+// Run plays laps with the agent until the context is done.
+func Run(ctx context.Context, env *Env) {
+	// Create the session directory for the overridden ticks.
+	sessionDir := ""
+	if env.HumanOverride {
+		sessionDir = filepath.Join(env.RecordingsDir, recording.SessionName(time.Now()))
+	}
+
+	// Serve the metrics, if enabled.
+	var sessionMetrics *metrics.Metrics
+	if env.MetricsAddr != "" {
+		sessionMetrics = metrics.New()
+
+		go func() {
+			if err := sessionMetrics.Serve(ctx, env.MetricsAddr); err != nil {
+				log.Println(err)
+			}
+		}()
+	}
+
+	// Serve the dashboard, if enabled.
+	var board *dashboard.Dashboard
+	if env.DashboardAddr != "" {
+		board = dashboard.New()
+
+		go func() {
+			if err := board.Serve(ctx, env.DashboardAddr); err != nil {
+				log.Println(err)
+			}
+		}()
+	}
+
+	// Open the browsers.
+	gameConfig := game.ClientConfig{
+		BrowserBin:      env.BrowserBin,
+		BrowserHeadless: env.BrowserHeadless,
+		BrowserWSURL:    env.BrowserWSURL,
+		Debug:           env.GameDebug,
+		FPS:             env.FramesPerSecond,
+		GameURL:         env.GameURL,
+		Metrics:         sessionMetrics,
+		NewWindow:       env.WorkersNum > 1,
+		WindowHeight:    env.WindowHeight,
+		WindowWidth:     env.WindowWidth,
+	}
+
+	browsers, err := game.OpenBrowsers(ctx, gameConfig, env.BrowsersNum)
+	if err != nil {
+		log.Fatalf("failed to open browsers: %v", err)
+	}
+	defer game.CloseBrowsers(browsers)
+
+	// Create the workers, spread across the browsers.
+	workers := make([]*worker, 0, env.WorkersNum)
+	for i := range env.WorkersNum {
+		w, err := newWorker(ctx, env, browsers[i%len(browsers)], gameConfig, sessionMetrics, board, sessionDir, i)
+		if err != nil {
+			log.Fatalf("failed to create worker %d: %v", i, err)
+		}
+		defer w.close()
+
+		workers = append(workers, w)
+	}
+
+	// Play laps across the workers until stopped, writing each result to stdout.
+	lapResults := game.NewLapResultWriter(os.Stdout)
+
+	// The results are only counted, since the laps go on until stopped.
+	played, failed := 0, 0
+	pool.Stream(ctx, len(workers), 0,
+		func(ctx context.Context, i int, lap int) (game.LapResult, error) {
+			result, err := workers[i].playLap(ctx, lap)
+			if result.Status == game.LapFinished {
+				sessionMetrics.LapCompleted(result.LapTime)
+			} else {
+				sessionMetrics.LapFailed()
+			}
+
+			if writeErr := lapResults.Write(result); writeErr != nil {
+				log.Println(writeErr)
+			}
+
+			return result, err
+		},
+		func(result pool.Result[game.LapResult]) {
+			played++
+			if result.Err != nil {
+				failed++
+			}
+		})
+
+	log.Println(fmt.Sprintf("played %d laps, %d failed", played-failed, failed))
+}
+
+// playLap plays a single lap of the game.
+func playLap(
+	ctx context.Context,
+	gameClient *game.Client,
+	driverConfig driver.Configuration,
+	timeout time.Duration,
+	logger *log.Logger,
+) (game.LapResult, error) {
+	// Create the driver.
+	driverClient := driver.New(driverConfig)
+
+	result, err := driverClient.Lap(ctx, gameClient, timeout)
+
+	// Report where the tick budget went.
+	timings := driverClient.Timings()
+	logger.Println(fmt.Sprintf("lap timings: capture: %s", timings.Capture))
+	logger.Println(fmt.Sprintf("lap timings: infer: %s", timings.Infer))
+	logger.Println(fmt.Sprintf("lap timings: apply: %s", timings.Apply))
+	logger.Println(fmt.Sprintf("lap timings: latency: %s, dropped: %d", timings.Latency, timings.Dropped))
+
+	if result.LoopErrors > 0 {
+		logger.Println(fmt.Sprintf("game loop failed on %d of %d ticks", result.LoopErrors, result.Ticks))
+	}
+
+	return result, err
+}
+
+// percent returns the percentage of part in total.
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return 100 * float64(part) / float64(total)
+}
+
+// newAgent creates the agent that drives the car.
+// Several agent URLs make an ensemble.
+func newAgent(env *Env, sessionMetrics *metrics.Metrics) (agent.Agent, error) {
+	if len(env.AgentURLs) == 1 {
+		return agent.New(agent.ClientConfiguration{
+			APIURL:  env.AgentURLs[0],
+			Debug:   env.AgentDebug,
+			Metrics: sessionMetrics,
+			Timeout: env.AgentTimeout,
+		})
+	}
+
+	members := make([]agent.EnsembleMember, 0, len(env.AgentURLs))
+	for _, url := range env.AgentURLs {
+		member, err := agent.New(agent.ClientConfiguration{
+			APIURL:  url,
+			Debug:   env.AgentDebug,
+			Metrics: sessionMetrics,
+			Timeout: env.AgentTimeout,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create ensemble member %s: %w", url, err)
+		}
+
+		members = append(members, agent.EnsembleMember{
+			Agent: member,
+			Name:  url,
+		})
+	}
+
+	return agent.NewEnsemble(agent.EnsembleConfiguration{
+		Debug:    env.AgentDebug,
+		Members:  members,
+		Strategy: env.AgentEnsembleStrategy,
+		Timeout:  env.AgentTimeout,
+	})
+}
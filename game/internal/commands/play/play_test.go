@@ -0,0 +1,107 @@
+package play
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-rod/rod"
+
+	"github.com/nizarmah/stig/game/internal/agent"
+	"github.com/nizarmah/stig/game/internal/env"
+	"github.com/nizarmah/stig/game/internal/game"
+	"github.com/nizarmah/stig/game/internal/mockgame/mockgametest"
+)
+
+func TestPlayLapOnMockGame(t *testing.T) {
+	bin := mockgametest.BrowserBin(t)
+	gameURL := mockgametest.Serve(t)
+
+	// The agent reads the car off the page, since the page is only known once the worker opened it.
+	page := &atomic.Pointer[rod.Page]{}
+	agentServer := httptest.NewServer(steeringAgent(page))
+	defer agentServer.Close()
+
+	cfg := &Env{}
+	if err := env.Load(cfg, "play", []string{
+		"--agent-url", agentServer.URL,
+		"--browser-bin", bin,
+		"--browser-headless",
+		"--game-url", gameURL,
+		"--lap-timeout", "60",
+	}, io.Discard); err != nil {
+		t.Fatalf("failed to load settings: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	gameConfig := game.ClientConfig{
+		BrowserBin:      cfg.BrowserBin,
+		BrowserHeadless: cfg.BrowserHeadless,
+		FPS:             cfg.FramesPerSecond,
+		GameURL:         cfg.GameURL,
+		WindowHeight:    cfg.WindowHeight,
+		WindowWidth:     cfg.WindowWidth,
+	}
+
+	browsers, err := game.OpenBrowsers(ctx, gameConfig, 1)
+	if err != nil {
+		t.Fatalf("failed to open browsers: %v", err)
+	}
+	defer game.CloseBrowsers(browsers)
+
+	w, err := newWorker(ctx, cfg, browsers[0], gameConfig, nil, nil, "", 0)
+	if err != nil {
+		t.Fatalf("failed to create worker: %v", err)
+	}
+	defer w.close()
+
+	page.Store(w.gameClient.Page)
+
+	result, err := w.playLap(ctx, 0)
+	if err != nil {
+		t.Fatalf("playLap() error = %v", err)
+	}
+
+	if result.Status != game.LapFinished || result.LapTime <= 0 {
+		t.Errorf("playLap() = %s in %s, want a finished lap", result.Status, result.LapTime)
+	}
+
+	if result.Ticks == 0 {
+		t.Error("playLap() played no ticks")
+	}
+}
+
+// steeringAgent serves an agent that accelerates and steers toward the road ahead.
+func steeringAgent(page *atomic.Pointer[rod.Page]) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /info", func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(agent.Info{
+			Image: agent.ImageInfo{Format: "jpeg"},
+			Model: "steering",
+		})
+	})
+
+	mux.HandleFunc("POST /act", func(w http.ResponseWriter, r *http.Request) {
+		// The agent accelerates until it knows the page.
+		action := game.Action{Throttle: game.ThrottleAccelerate}
+		if p := page.Load(); p != nil {
+			var err error
+			if action, err = mockgametest.Steer(r.Context(), p); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		json.NewEncoder(w).Encode(action)
+	})
+
+	return mux
+}
@@ -0,0 +1,298 @@
+package play
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/agent"
+	"github.com/nizarmah/stig/game/internal/controller"
+	"github.com/nizarmah/stig/game/internal/dashboard"
+	"github.com/nizarmah/stig/game/internal/driver"
+	"github.com/nizarmah/stig/game/internal/game"
+	"github.com/nizarmah/stig/game/internal/metrics"
+	"github.com/nizarmah/stig/game/internal/recording"
+	"github.com/nizarmah/stig/game/internal/screen"
+)
+
+// worker plays laps on its own game page, with its own agent.
+type worker struct {
+	// agentClient is the agent that drives, before any shadow or override.
+	agentClient *agent.Fallback
+	// agentInfo is the description of the agent.
+	agentInfo agent.Info
+	// agents are the agents the worker created, closed along with it.
+	agents []agent.Agent
+	// controllerWatcher watches the keys a human presses on the page, if enabled.
+	controllerWatcher *controller.Watcher
+	// driverConfig is the configuration of the driver of each lap.
+	driverConfig driver.Configuration
+	// gameClient is the game on the page.
+	gameClient *game.Client
+	// index is the index of the worker.
+	index int
+	// lapTimeout is the timeout for a single lap.
+	lapTimeout time.Duration
+	// log is the log of the worker.
+	log *log.Logger
+	// recorder records the ticks a human overrides, if enabled.
+	recorder *overrideRecorder
+	// shadowAgent is the agent evaluated alongside, if enabled.
+	shadowAgent *agent.Shadow
+	// shadowLog is the file the shadow comparison is written to, if enabled.
+	shadowLog *os.File
+	// view is the view of the worker on the dashboard, if enabled.
+	view *dashboard.Worker
+}
+
+// newWorker creates a new worker on a new page of the browser.
+// With several workers, each one gets its own log prefix, recordings directory and shadow log.
+func newWorker(
+	ctx context.Context,
+	env *Env,
+	browser *game.Browser,
+	gameConfig game.ClientConfig,
+	sessionMetrics *metrics.Metrics,
+	board *dashboard.Dashboard,
+	sessionDir string,
+	index int,
+) (*worker, error) {
+	w := &worker{
+		index:      index,
+		lapTimeout: env.LapTimeout,
+		log:        log.Default(),
+	}
+
+	name := recording.WorkerName(index)
+	if env.WorkersNum > 1 {
+		w.log = log.New(os.Stderr, fmt.Sprintf("[%s] ", name), log.LstdFlags)
+	}
+
+	// Create the game client.
+	gameClient, err := browser.NewClient(ctx, gameConfig, env.GameTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create game client: %w", err)
+	}
+
+	w.gameClient = gameClient
+
+	// Create the controller client.
+	controllerClient := controller.NewClient(gameClient.Page)
+
+	// Show the worker on the dashboard.
+	w.view = board.Worker(index, gameClient, controllerClient)
+
+	// Create the screen client.
+	screenClient := screen.NewClient(screen.ClientConfiguration{
+		Debug:        env.ScreenDebug,
+		Metrics:      sessionMetrics,
+		Page:         gameClient.Page,
+		Resolution:   env.ScreenResolution,
+		WindowHeight: env.WindowHeight,
+		WindowWidth:  env.WindowWidth,
+	})
+
+	// The agents resize the captured frames to the images they expect.
+	frame := agent.Frame{Height: env.WindowHeight, Width: env.WindowWidth}
+
+	// Create the agent.
+	baseAgent, err := newAgent(env, sessionMetrics)
+	if err != nil {
+		w.close()
+		return nil, fmt.Errorf("failed to create agent: %w", err)
+	}
+
+	w.agents = append(w.agents, baseAgent)
+
+	w.agentClient = agent.NewFallback(agent.FallbackConfiguration{
+		Agent:  baseAgent,
+		Debug:  env.AgentDebug,
+		Policy: env.AgentFallback,
+	})
+
+	var lapAgent agent.Agent = w.agentClient
+
+	// Evaluate a shadow agent alongside, without letting it drive.
+	if env.ShadowAgentURL != "" {
+		shadowClient, err := agent.New(agent.ClientConfiguration{
+			APIURL:  env.ShadowAgentURL,
+			Debug:   env.AgentDebug,
+			Timeout: env.AgentTimeout,
+		})
+		if err != nil {
+			w.close()
+			return nil, fmt.Errorf("failed to create shadow agent: %w", err)
+		}
+
+		w.agents = append(w.agents, shadowClient)
+
+		shadowInfo, err := agent.Handshake(ctx, shadowClient, frame)
+		if err != nil {
+			w.close()
+			return nil, fmt.Errorf("failed to handshake with shadow agent: %w", err)
+		}
+
+		w.log.Println(fmt.Sprintf("shadow agent: %s", shadowInfo))
+
+		shadowLogFile := env.ShadowLogFile
+		if env.WorkersNum > 1 {
+			ext := filepath.Ext(shadowLogFile)
+			shadowLogFile = fmt.Sprintf("%s.%s%s", strings.TrimSuffix(shadowLogFile, ext), name, ext)
+		}
+
+		w.shadowLog, err = os.OpenFile(shadowLogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			w.close()
+			return nil, fmt.Errorf("failed to open shadow log file: %w", err)
+		}
+
+		w.shadowAgent = agent.NewShadow(agent.ShadowConfiguration{
+			Debug:   env.AgentDebug,
+			Log:     w.shadowLog,
+			Primary: lapAgent,
+			Shadow:  shadowClient,
+		})
+		lapAgent = w.shadowAgent
+	}
+
+	// Let a human take over, and record where they do.
+	if env.HumanOverride {
+		w.controllerWatcher, err = controller.NewWatcher(ctx, controller.WatcherConfiguration{
+			Debug:      env.ControllerDebug,
+			IgnoreKeys: controller.Keys(),
+			Page:       gameClient.Page,
+		})
+		if err != nil {
+			w.close()
+			return nil, fmt.Errorf("failed to create controller watcher: %w", err)
+		}
+
+		recordingsDir := sessionDir
+		if env.WorkersNum > 1 {
+			recordingsDir = filepath.Join(sessionDir, name)
+		}
+
+		w.recorder, err = newOverrideRecorder(recordingsDir)
+		if err != nil {
+			w.close()
+			return nil, fmt.Errorf("failed to create override recorder: %w", err)
+		}
+
+		lapAgent = agent.NewOverride(agent.OverrideConfiguration{
+			Agent:      lapAgent,
+			Debug:      env.AgentDebug,
+			Human:      w.controllerWatcher,
+			OnOverride: w.recorder.record,
+		})
+	}
+
+	// Check the agent is up and compatible before racing.
+	w.agentInfo, err = agent.Handshake(ctx, lapAgent, frame)
+	if err != nil {
+		w.close()
+		return nil, fmt.Errorf("failed to handshake with agent: %w", err)
+	}
+
+	w.log.Println(fmt.Sprintf("agent: %s", w.agentInfo))
+
+	w.driverConfig = driver.Configuration{
+		Agent:      lapAgent,
+		Controller: controllerClient,
+		Debug:      env.AgentDebug,
+		Depth:      env.PipelineDepth,
+		Metrics:    sessionMetrics,
+		OnTick:     w.view.Observe,
+		Screen:     screenClient,
+	}
+
+	return w, nil
+}
+
+// close closes the page, the agents, the controller watcher, the shadow log and the override recorder of the worker.
+func (w *worker) close() {
+	for _, a := range w.agents {
+		agent.Close(a)
+	}
+
+	if w.controllerWatcher != nil {
+		w.controllerWatcher.Close()
+	}
+
+	if w.gameClient != nil {
+		w.gameClient.Close()
+	}
+
+	if w.shadowLog != nil {
+		w.shadowLog.Close()
+	}
+
+	if w.recorder != nil {
+		w.recorder.endLap()
+	}
+}
+
+// playLap plays a lap, logs its summary and returns its result.
+func (w *worker) playLap(ctx context.Context, lap int) (game.LapResult, error) {
+	w.agentClient.Reset()
+	if w.shadowAgent != nil {
+		w.shadowAgent.Reset()
+	}
+	if w.recorder != nil {
+		w.recorder.startLap(lap)
+	}
+	w.view.StartLap(lap)
+
+	result, err := playLap(
+		ctx,
+		w.gameClient,
+		w.driverConfig,
+		w.lapTimeout,
+		w.log,
+	)
+
+	if w.recorder != nil {
+		if lost, err := w.recorder.endLap(); err != nil {
+			w.log.Println(fmt.Sprintf("failed to save %d overridden frames of lap %d: %v", lost, lap, err))
+		} else if lost > 0 {
+			w.log.Println(fmt.Sprintf("dropped %d overridden frames of lap %d: recording queue was full", lost, lap))
+		}
+	}
+
+	fallbacks := w.agentClient.Stats()
+	result.AgentErrors = fallbacks.Total()
+	result.Lap = lap
+	result.Worker = w.index
+
+	w.log.Println(fmt.Sprintf(
+		"lap %d summary: agent: %s, fallbacks: %d (timeouts: %d, errors: %d)",
+		lap,
+		w.agentInfo.Model,
+		fallbacks.Total(),
+		fallbacks.Timeouts,
+		fallbacks.Errors,
+	))
+
+	if w.shadowAgent != nil {
+		agreed, compared := w.shadowAgent.Agreement()
+		w.log.Println(fmt.Sprintf(
+			"lap %d summary: shadow agreement: %.1f%% (%d/%d ticks)",
+			lap,
+			percent(agreed, compared),
+			agreed,
+			compared,
+		))
+	}
+
+	if err != nil {
+		w.log.Println(fmt.Sprintf("failed to play lap %d: %v", lap, err))
+		return result, err
+	}
+
+	w.log.Println(fmt.Sprintf("lap %d time: %s", lap, result.LapTime))
+
+	return result, nil
+}
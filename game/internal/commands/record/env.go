@@ -0,0 +1,37 @@
+package record
+
+import (
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/commands"
+)
+
+// Env represents the configuration of the record command.
+type Env struct {
+	commands.Game
+
+	// BrowsersNum is the number of browsers to spread the workers across.
+	BrowsersNum int `env:"BROWSERS_NUM" default:"1" min:"1" help:"number of browsers to spread the workers across"`
+	// ControllerDebug is whether to debug the controller package.
+	ControllerDebug bool `env:"CONTROLLER_DEBUG" default:"false" help:"whether to debug the controller"`
+	// LapTimeout is the timeout for a single lap (seconds).
+	LapTimeout time.Duration `env:"LAP_TIMEOUT" default:"300" unit:"s" min:"1" help:"timeout for a single lap, in seconds"`
+	// LapsNum is the number of laps to record.
+	LapsNum int `env:"LAPS_NUM" default:"30" min:"1" help:"number of laps to record"`
+	// MetricsAddr is the address to serve the metrics on (empty disables it).
+	MetricsAddr string `env:"METRICS_ADDR" help:"address to serve the metrics on (empty disables it)"`
+	// RecordingMaxLapTime is the highest lap time to keep a lap (milliseconds, 0 disables it).
+	RecordingMaxLapTime time.Duration `env:"RECORDING_MAX_LAP_TIME" default:"0" unit:"ms" min:"0" help:"highest lap time to keep a lap, in milliseconds (0 disables it)"`
+	// RecordingMinFrames is the lowest number of frames to keep a lap (0 disables it).
+	RecordingMinFrames int `env:"RECORDING_MIN_FRAMES" default:"0" min:"0" help:"lowest number of frames to keep a lap (0 disables it)"`
+	// RecordingQueueSize is the number of frames that can wait to be written before new ones are dropped.
+	RecordingQueueSize int `env:"RECORDING_QUEUE_SIZE" default:"64" min:"1" help:"number of frames that can wait to be written before new ones are dropped"`
+	// RecordingsDir is the directory to output the recordings.
+	RecordingsDir string `env:"RECORDINGS_DIR" default:"assets/recordings/" help:"directory to output the recordings"`
+	// ScreenDebug is whether to debug the screen package.
+	ScreenDebug bool `env:"SCREEN_DEBUG" default:"false" help:"whether to debug the screen"`
+	// ScreenResolution is the resolution of the screen.
+	ScreenResolution int `env:"SCREEN_RESOLUTION" default:"100" min:"0" max:"100" help:"resolution of the screen"`
+	// WorkersNum is the number of game pages playing laps in parallel.
+	WorkersNum int `env:"WORKERS_NUM" default:"1" min:"1" help:"number of game pages playing laps in parallel"`
+}
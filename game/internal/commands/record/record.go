@@ -0,0 +1,235 @@
+// Package record records the gameplay for supervised learning.
+package record
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/env"
+	"github.com/nizarmah/stig/game/internal/game"
+	"github.com/nizarmah/stig/game/internal/metrics"
+	"github.com/nizarmah/stig/game/internal/pool"
+	"github.com/nizarmah/stig/game/internal/recording"
+	"github.com/nizarmah/stig/game/internal/screen"
+)
+
+// Run records the laps.
+func Run(ctx context.Context, env *Env) {
+	// Create the session directory.
+	start := time.Now()
+	sessionDir := filepath.Join(env.RecordingsDir, recording.SessionName(start))
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		log.Fatalf("failed to create session directory: %v", err)
+	}
+
+	if err := writeSession(sessionDir, env, start); err != nil {
+		log.Fatalf("failed to describe session: %v", err)
+	}
+
+	// Serve the metrics, if enabled.
+	var sessionMetrics *metrics.Metrics
+	if env.MetricsAddr != "" {
+		sessionMetrics = metrics.New()
+
+		go func() {
+			if err := sessionMetrics.Serve(ctx, env.MetricsAddr); err != nil {
+				log.Println(err)
+			}
+		}()
+	}
+
+	// Open the browsers.
+	gameConfig := game.ClientConfig{
+		BrowserBin:      env.BrowserBin,
+		BrowserHeadless: env.BrowserHeadless,
+		BrowserWSURL:    env.BrowserWSURL,
+		Debug:           env.GameDebug,
+		FPS:             env.FramesPerSecond,
+		GameURL:         env.GameURL,
+		Metrics:         sessionMetrics,
+		NewWindow:       env.WorkersNum > 1,
+		WindowHeight:    env.WindowHeight,
+		WindowWidth:     env.WindowWidth,
+	}
+
+	browsers, err := game.OpenBrowsers(ctx, gameConfig, env.BrowsersNum)
+	if err != nil {
+		log.Fatalf("failed to open browsers: %v", err)
+	}
+	defer game.CloseBrowsers(browsers)
+
+	// Create the workers, spread across the browsers.
+	workers := make([]*worker, 0, env.WorkersNum)
+	for i := range env.WorkersNum {
+		w, err := newWorker(ctx, env, browsers[i%len(browsers)], gameConfig, sessionMetrics, sessionDir, i)
+		if err != nil {
+			log.Fatalf("failed to create worker %d: %v", i, err)
+		}
+		defer w.close()
+
+		workers = append(workers, w)
+	}
+
+	// Record the laps across the workers, writing each result to stdout.
+	lapResults := game.NewLapResultWriter(os.Stdout)
+
+	results := pool.Run(ctx, len(workers), env.LapsNum,
+		func(ctx context.Context, i int, lap int) (game.LapResult, error) {
+			result, err := workers[i].recordLap(ctx, lap)
+			if result.Status == game.LapFinished {
+				sessionMetrics.LapCompleted(result.LapTime)
+			} else {
+				sessionMetrics.LapFailed()
+			}
+
+			if writeErr := lapResults.Write(result); writeErr != nil {
+				log.Println(writeErr)
+			}
+
+			return result, err
+		})
+
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+
+	log.Println(fmt.Sprintf("recorded %d laps, %d failed", len(results)-failed, failed))
+}
+
+// writeSession describes the session, with the configuration it's recorded with.
+func writeSession(dir string, cfg *Env, start time.Time) error {
+	values, err := env.Values(cfg)
+	if err != nil {
+		return err
+	}
+
+	return recording.WriteSession(dir, recording.Session{
+		Command: "record",
+		Config:  values,
+		Start:   start,
+	})
+}
+
+// recordLap records a single lap of the game,
+// and returns its result along with the final time shown on the Replay screen.
+func recordLap(
+	parentCtx context.Context,
+	gameClient *game.Client,
+	screenClient *screen.Client,
+	writer *recording.Writer,
+	interval time.Duration,
+	timeout time.Duration,
+	logger *log.Logger,
+) (game.LapResult, string, error) {
+	result := game.LapResult{}
+
+	// Lap context.
+	ctx, cancel := context.WithTimeout(parentCtx, timeout)
+	defer cancel()
+
+	// Reset the game, and wait for the race to start.
+	if err := gameClient.ResetGame(ctx); err != nil {
+		err = fmt.Errorf("failed to reset game: %w", err)
+		result.Fail(err)
+		return result, "", err
+	}
+
+	loopCtx, stopLoop := context.WithCancel(ctx)
+	defer stopLoop()
+
+	var loopStats game.LoopStats
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		loopStats, _ = gameClient.RunInGameLoop(loopCtx,
+			recordGameplay(screenClient, writer, interval, logger))
+	}()
+
+	// Wait for the game to finish.
+	finishErr := gameClient.WaitForFinish(ctx)
+
+	// Stop recording before collecting the stats.
+	stopLoop()
+	<-done
+
+	result.Ticks = loopStats.Ticks
+	result.DroppedTicks = loopStats.Missed
+	result.LoopErrors = loopStats.Errors
+
+	if finishErr != nil {
+		err := fmt.Errorf("failed to wait for game to finish: %w", finishErr)
+		result.Fail(err)
+		return result, "", err
+	}
+
+	// Read the lap time.
+	replayTime, err := gameClient.GetReplayTime(ctx)
+	if err != nil {
+		err = fmt.Errorf("failed to get lap time: %w", err)
+		result.Fail(err)
+		return result, "", err
+	}
+
+	lapTime, err := game.ParseLapTime(replayTime)
+	if err != nil {
+		result.Fail(err)
+		return result, replayTime, err
+	}
+
+	result.LapTime = lapTime
+	result.Status = game.LapFinished
+
+	return result, replayTime, nil
+}
+
+// recordGameplay returns a tick of the game loop recording a frame.
+// The frames are written in the background, and labeled from the input timeline once the lap ended.
+// Ticks further apart than the interval of the loop are flagged as following dropped ticks.
+func recordGameplay(
+	screenClient *screen.Client,
+	writer *recording.Writer,
+	interval time.Duration,
+	logger *log.Logger,
+) func(ctx context.Context) error {
+	start := time.Now()
+	tick := 0
+	var lastCapture time.Time
+
+	return func(ctx context.Context) error {
+		defer func() { tick++ }()
+
+		// Capture the frame.
+		captureTime := time.Now()
+		frame, err := screenClient.Peek(ctx)
+		if err != nil {
+			logger.Println(fmt.Sprintf("failed to capture screen: %v", err))
+			return fmt.Errorf("failed to capture screen: %w", err)
+		}
+
+		capture := time.Since(captureTime)
+
+		dropped := !lastCapture.IsZero() && captureTime.Sub(lastCapture) > interval*3/2
+		lastCapture = captureTime
+
+		// Queue the frame and its description for the writer.
+		if !writer.Write(frame, recording.FrameEntry{
+			Capture: capture,
+			Dropped: dropped,
+			File:    recording.PendingFrameName(captureTime),
+			Offset:  captureTime.Sub(start),
+			Tick:    tick,
+			Time:    captureTime,
+		}) {
+			logger.Println(fmt.Sprintf("dropped frame of tick %d: recording queue is full", tick))
+		}
+
+		return nil
+	}
+}
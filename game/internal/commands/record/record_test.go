@@ -0,0 +1,118 @@
+package record
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/env"
+	"github.com/nizarmah/stig/game/internal/game"
+	"github.com/nizarmah/stig/game/internal/mockgame/mockgametest"
+	"github.com/nizarmah/stig/game/internal/recording"
+)
+
+func TestRecordLapOnMockGame(t *testing.T) {
+	bin := mockgametest.BrowserBin(t)
+	gameURL := mockgametest.Serve(t)
+
+	dir := t.TempDir()
+
+	cfg := &Env{}
+	if err := env.Load(cfg, "record", []string{
+		"--browser-bin", bin,
+		"--browser-headless",
+		"--game-url", gameURL,
+		"--lap-timeout", "60",
+		"--recordings-dir", dir,
+	}, io.Discard); err != nil {
+		t.Fatalf("failed to load settings: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	gameConfig := game.ClientConfig{
+		BrowserBin:      cfg.BrowserBin,
+		BrowserHeadless: cfg.BrowserHeadless,
+		FPS:             cfg.FramesPerSecond,
+		GameURL:         cfg.GameURL,
+		WindowHeight:    cfg.WindowHeight,
+		WindowWidth:     cfg.WindowWidth,
+	}
+
+	browsers, err := game.OpenBrowsers(ctx, gameConfig, 1)
+	if err != nil {
+		t.Fatalf("failed to open browsers: %v", err)
+	}
+	defer game.CloseBrowsers(browsers)
+
+	w, err := newWorker(ctx, cfg, browsers[0], gameConfig, nil, dir, 0)
+	if err != nil {
+		t.Fatalf("failed to create worker: %v", err)
+	}
+	defer w.close()
+
+	// Drive the car with the keyboard, as a human would, while the lap is recorded.
+	driveCtx, stopDriving := context.WithCancel(ctx)
+	driven := make(chan struct{})
+	go func() {
+		defer close(driven)
+		mockgametest.Drive(driveCtx, w.gameClient.Page)
+	}()
+
+	result, err := w.recordLap(ctx, 0)
+
+	stopDriving()
+	<-driven
+
+	if err != nil {
+		t.Fatalf("recordLap() error = %v", err)
+	}
+
+	if result.Status != game.LapFinished {
+		t.Fatalf("recordLap() = %s, want a finished lap", result.Status)
+	}
+
+	// The lap is kept, with its frames labeled once it ended.
+	lapDir := filepath.Join(dir, recording.LapName(0))
+	for _, file := range []string{recording.ManifestFile, recording.InputsFile} {
+		if info, err := os.Stat(filepath.Join(lapDir, file)); err != nil || info.Size() == 0 {
+			t.Errorf("lap is missing its %s: %v", file, err)
+		}
+	}
+
+	frames, err := recording.ReadLap(lapDir)
+	if err != nil {
+		t.Fatalf("ReadLap() error = %v", err)
+	}
+
+	if len(frames) == 0 {
+		t.Fatal("lap has no frames")
+	}
+
+	accelerating := 0
+	for _, frame := range frames {
+		if frame.Action.Throttle == game.ThrottleAccelerate {
+			accelerating++
+		}
+	}
+
+	if accelerating == 0 {
+		t.Errorf("none of the %d frames are labeled with the throttle held", len(frames))
+	}
+
+	entries, err := os.ReadDir(lapDir)
+	if err != nil {
+		t.Fatalf("failed to read lap: %v", err)
+	}
+
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "pending_") {
+			t.Errorf("frame %s was left unlabeled", entry.Name())
+		}
+	}
+}
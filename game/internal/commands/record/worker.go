@@ -0,0 +1,256 @@
+package record
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/controller"
+	"github.com/nizarmah/stig/game/internal/game"
+	"github.com/nizarmah/stig/game/internal/metrics"
+	"github.com/nizarmah/stig/game/internal/recording"
+	"github.com/nizarmah/stig/game/internal/screen"
+)
+
+// worker records laps on its own game page.
+type worker struct {
+	// controllerWatcher watches the keys pressed on the page.
+	controllerWatcher *controller.Watcher
+	// dir is the directory of the laps of the worker.
+	dir string
+	// gameClient is the game on the page.
+	gameClient *game.Client
+	// index is the index of the worker.
+	index int
+	// interval is the interval of the game loop.
+	interval time.Duration
+	// lapTimeout is the timeout for a single lap.
+	lapTimeout time.Duration
+	// log is the log of the worker.
+	log *log.Logger
+	// logFile is the file the log of the worker is written to, if any.
+	logFile *os.File
+	// metrics are the metrics of the session.
+	metrics *metrics.Metrics
+	// queueSize is the number of frames that can wait to be written.
+	queueSize int
+	// rules decide which recorded laps are kept.
+	rules recording.Rules
+	// screenClient captures the page.
+	screenClient *screen.Client
+}
+
+// newWorker creates a new worker on a new page of the browser.
+// With a single worker, laps go straight into the session directory.
+func newWorker(
+	ctx context.Context,
+	env *Env,
+	browser *game.Browser,
+	gameConfig game.ClientConfig,
+	sessionMetrics *metrics.Metrics,
+	sessionDir string,
+	index int,
+) (*worker, error) {
+	w := &worker{
+		dir:        sessionDir,
+		index:      index,
+		interval:   time.Second / time.Duration(env.FramesPerSecond),
+		lapTimeout: env.LapTimeout,
+		log:        log.Default(),
+		metrics:    sessionMetrics,
+		queueSize:  env.RecordingQueueSize,
+		rules: recording.Rules{
+			MaxLapTime: env.RecordingMaxLapTime,
+			MinFrames:  env.RecordingMinFrames,
+		},
+	}
+
+	if env.WorkersNum > 1 {
+		w.dir = filepath.Join(sessionDir, recording.WorkerName(index))
+		if err := os.MkdirAll(w.dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create worker directory: %w", err)
+		}
+
+		logFile, err := os.Create(filepath.Join(w.dir, "worker.log"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create worker log: %w", err)
+		}
+
+		w.logFile = logFile
+		w.log = log.New(
+			io.MultiWriter(os.Stderr, logFile),
+			fmt.Sprintf("[%s] ", recording.WorkerName(index)),
+			log.LstdFlags,
+		)
+	}
+
+	// Create the game client.
+	gameClient, err := browser.NewClient(ctx, gameConfig, env.GameTimeout)
+	if err != nil {
+		w.close()
+		return nil, fmt.Errorf("failed to create game client: %w", err)
+	}
+
+	w.gameClient = gameClient
+
+	// Create the controller watcher.
+	w.controllerWatcher, err = controller.NewWatcher(ctx, controller.WatcherConfiguration{
+		Debug: env.ControllerDebug,
+		Page:  gameClient.Page,
+	})
+	if err != nil {
+		w.close()
+		return nil, fmt.Errorf("failed to create controller watcher: %w", err)
+	}
+
+	// Create the screen client.
+	w.screenClient = screen.NewClient(screen.ClientConfiguration{
+		Debug:        env.ScreenDebug,
+		Metrics:      sessionMetrics,
+		Page:         gameClient.Page,
+		Resolution:   env.ScreenResolution,
+		WindowHeight: env.WindowHeight,
+		WindowWidth:  env.WindowWidth,
+	})
+
+	return w, nil
+}
+
+// close closes the controller watcher, the page and the log of the worker.
+func (w *worker) close() {
+	if w.controllerWatcher != nil {
+		w.controllerWatcher.Close()
+	}
+
+	if w.gameClient != nil {
+		w.gameClient.Close()
+	}
+
+	if w.logFile != nil {
+		w.logFile.Close()
+	}
+}
+
+// recordLap records a lap into its own staging directory,
+// then promotes it among the recorded laps if it's kept, or quarantines it.
+func (w *worker) recordLap(ctx context.Context, lap int) (game.LapResult, error) {
+	// Create the lap directory.
+	lapDir, err := recording.StageLap(w.dir, lap)
+	if err != nil {
+		w.log.Println(fmt.Sprintf("failed to create lap %d directory: %v", lap, err))
+
+		result := game.LapResult{Lap: lap, Worker: w.index}
+		result.Fail(err)
+		return result, err
+	}
+
+	manifest, err := recording.CreateManifest(lapDir)
+	if err != nil {
+		w.log.Println(fmt.Sprintf("failed to create lap %d manifest: %v", lap, err))
+
+		result := game.LapResult{Lap: lap, Worker: w.index}
+		result.Fail(err)
+		return result, err
+	}
+
+	// Record the input timeline of the lap, to label its frames once it ended.
+	if err := w.controllerWatcher.StartTimeline(ctx); err != nil {
+		w.log.Println(fmt.Sprintf("failed to start lap %d input timeline: %v", lap, err))
+		manifest.Close()
+
+		result := game.LapResult{Lap: lap, Worker: w.index}
+		result.Fail(err)
+		return result, err
+	}
+
+	writer := recording.NewWriter(recording.WriterConfiguration{
+		Dir:       lapDir,
+		Metrics:   w.metrics,
+		QueueSize: w.queueSize,
+	})
+
+	// Record the lap.
+	result, replayTime, err := recordLap(
+		ctx,
+		w.gameClient,
+		w.screenClient,
+		writer,
+		w.interval,
+		w.lapTimeout,
+		w.log,
+	)
+	result.Lap = lap
+	result.Worker = w.index
+
+	// Write the frames still queued before finishing the lap, even on shutdown.
+	stats, writeErr := writer.Close()
+	if writeErr != nil {
+		w.log.Println(fmt.Sprintf("failed to write %d frames of lap %d: %v", stats.Errors, lap, writeErr))
+	}
+
+	if stats.Dropped > 0 {
+		w.log.Println(fmt.Sprintf("dropped %d frames of lap %d: recording queue was full", stats.Dropped, lap))
+	}
+
+	// Label the frames from the key events of the whole lap,
+	// so the events pushed while a frame was captured are in.
+	timeline := w.controllerWatcher.StopTimeline()
+	if err := recording.WriteInputs(lapDir, timeline.Events); err != nil {
+		w.log.Println(fmt.Sprintf("failed to write lap %d inputs: %v", lap, err))
+	}
+
+	labelErrors, labelErr := recording.LabelFrames(lapDir, manifest, writer.Frames(), timeline)
+	if labelErr != nil {
+		w.log.Println(fmt.Sprintf("failed to label %d frames of lap %d: %v", labelErrors, lap, labelErr))
+	}
+
+	entry, manifestErr := manifest.WriteLap(recording.LapEntry{
+		LapResult:     result,
+		DroppedFrames: stats.Dropped,
+		FrameErrors:   stats.Errors + labelErrors,
+		ReplayTime:    replayTime,
+	})
+	if manifestErr != nil {
+		w.log.Println(fmt.Sprintf("failed to finish lap %d manifest: %v", lap, manifestErr))
+	}
+
+	if err := manifest.Close(); err != nil {
+		w.log.Println(fmt.Sprintf("failed to close lap %d manifest: %v", lap, err))
+	}
+
+	// Keep the lap only if it qualifies.
+	w.finalizeLap(lap, lapDir, entry)
+
+	if err != nil {
+		w.log.Println(fmt.Sprintf("failed to record lap %d: %v", lap, err))
+		return result, err
+	}
+
+	w.log.Println(fmt.Sprintf("lap %d time: %s", lap, result.LapTime))
+
+	return result, nil
+}
+
+// finalizeLap promotes a staged lap among the recorded laps if the rules keep it,
+// or quarantines it with the reason it was rejected.
+func (w *worker) finalizeLap(lap int, lapDir string, entry recording.LapEntry) {
+	reason := w.rules.Reject(entry)
+	if reason == "" {
+		if _, err := recording.Promote(w.dir, lapDir); err != nil {
+			w.log.Println(fmt.Sprintf("failed to promote lap %d: %v", lap, err))
+		}
+
+		return
+	}
+
+	if _, err := recording.Quarantine(w.dir, lapDir, reason); err != nil {
+		w.log.Println(fmt.Sprintf("failed to quarantine lap %d: %v", lap, err))
+		return
+	}
+
+	w.log.Println(fmt.Sprintf("quarantined lap %d: %s", lap, reason))
+}
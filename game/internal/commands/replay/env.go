@@ -0,0 +1,19 @@
+package replay
+
+import (
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/commands"
+)
+
+// Env represents the configuration of the replay command.
+type Env struct {
+	commands.Game
+
+	// LapTimeout is the timeout for a single lap (seconds).
+	LapTimeout time.Duration `env:"LAP_TIMEOUT" default:"120" unit:"s" min:"1" help:"timeout for a single lap, in seconds"`
+	// LapsNum is the number of times to replay the lap.
+	LapsNum int `env:"LAPS_NUM" default:"3" min:"1" help:"number of times to replay the lap"`
+	// ReplayDir is the directory of the recorded lap to replay.
+	ReplayDir string `env:"REPLAY_DIR" required:"true" help:"directory of the recorded lap to replay"`
+}
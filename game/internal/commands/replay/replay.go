@@ -0,0 +1,117 @@
+// Package replay drives the car with the actions of a recorded lap.
+package replay
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/agent"
+	"github.com/nizarmah/stig/game/internal/controller"
+	"github.com/nizarmah/stig/game/internal/game"
+)
+
+// Run replays the recorded lap.
+func Run(ctx context.Context, env *Env) {
+	// Load the replay.
+	replay, err := agent.LoadReplay(env.ReplayDir)
+	if err != nil {
+		log.Fatalf("failed to load replay: %v", err)
+	}
+
+	// Create the game client.
+	gameClient, err := game.NewClient(ctx, game.ClientConfig{
+		BrowserBin:      env.BrowserBin,
+		BrowserHeadless: env.BrowserHeadless,
+		BrowserWSURL:    env.BrowserWSURL,
+		Debug:           env.GameDebug,
+		GameURL:         env.GameURL,
+		WindowHeight:    env.WindowHeight,
+		WindowWidth:     env.WindowWidth,
+	}, env.GameTimeout)
+	if err != nil {
+		log.Fatalf("failed to create game client: %v", err)
+	}
+	defer gameClient.Close()
+
+	// Create the controller client.
+	controllerClient := controller.NewClient(gameClient.Page)
+
+	// Start the replay loop.
+	for lap := range env.LapsNum {
+		select {
+		case <-ctx.Done():
+			return
+
+		default:
+			lapTime, err := replayLap(
+				ctx,
+				gameClient,
+				controllerClient,
+				replay,
+				env.LapTimeout,
+			)
+			if err != nil {
+				log.Println(fmt.Sprintf("failed to replay lap %d: %v", lap, err))
+				continue
+			}
+
+			log.Println(fmt.Sprintf(
+				"replayed lap %d: lap time: %s, recorded lap duration: %v",
+				lap,
+				lapTime,
+				replay.Duration().Round(time.Millisecond),
+			))
+		}
+	}
+}
+
+// replayLap replays a single lap of the game and returns its lap time.
+func replayLap(
+	parentCtx context.Context,
+	gameClient *game.Client,
+	controllerClient *controller.Client,
+	replay *agent.Replay,
+	timeout time.Duration,
+) (string, error) {
+	// Lap context.
+	ctx, cancel := context.WithTimeout(parentCtx, timeout)
+	defer cancel()
+
+	// Reset the game, and wait for the race to start.
+	if err := gameClient.ResetGame(ctx); err != nil {
+		return "", fmt.Errorf("failed to reset game: %w", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		if err := replay.Drive(ctx, controllerClient.Apply); err != nil && ctx.Err() == nil {
+			log.Println(fmt.Sprintf("failed to drive replay: %v", err))
+		}
+	}()
+
+	// Release the keys once the replay is over.
+	defer func() {
+		cancel()
+		<-done
+
+		if err := controllerClient.Apply(game.Action{}); err != nil {
+			log.Println(fmt.Sprintf("failed to release keys: %v", err))
+		}
+	}()
+
+	// Wait for the game to finish.
+	if err := gameClient.WaitForFinish(ctx); err != nil {
+		return "", fmt.Errorf("failed to wait for game to finish: %w", err)
+	}
+
+	lapTime, err := gameClient.GetReplayTime(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get lap time: %w", err)
+	}
+
+	return lapTime, nil
+}
@@ -0,0 +1,38 @@
+package tournament
+
+import (
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/agent"
+	"github.com/nizarmah/stig/game/internal/commands"
+)
+
+// Env represents the configuration of the tournament command.
+type Env struct {
+	commands.Game
+
+	// AgentDebug is whether to debug the agent client.
+	AgentDebug bool `env:"AGENT_DEBUG" default:"false" help:"whether to debug the agent clients"`
+	// AgentFallback is the policy to apply when the agent fails to act in time.
+	AgentFallback agent.FallbackPolicy `env:"AGENT_FALLBACK" default:"neutral" enum:"hold,neutral,brake" help:"policy when an agent fails to act in time"`
+	// AgentTimeout is the timeout for the agent to act (milliseconds).
+	AgentTimeout time.Duration `env:"AGENT_TIMEOUT_MS" default:"200" unit:"ms" replaces:"AGENT_TIMEOUT" replacedunit:"s" min:"1" help:"timeout for an agent to act, in milliseconds"`
+	// LapTimeout is the timeout for a single lap (seconds).
+	LapTimeout time.Duration `env:"LAP_TIMEOUT" default:"120" unit:"s" min:"1" help:"timeout for a single lap, in seconds"`
+	// LapsNum is the number of laps each agent plays.
+	LapsNum int `env:"LAPS_NUM" default:"10" min:"1" help:"number of laps each agent plays"`
+	// PipelineDepth is the number of frames inferred concurrently (0 disables pipelining).
+	PipelineDepth int `env:"PIPELINE_DEPTH" default:"0" min:"0" help:"number of frames inferred concurrently (0 disables pipelining)"`
+	// ScreenDebug is whether to debug the screen package.
+	ScreenDebug bool `env:"SCREEN_DEBUG" default:"false" help:"whether to debug the screen"`
+	// ScreenResolution is the resolution of the screen.
+	ScreenResolution int `env:"SCREEN_RESOLUTION" default:"100" min:"0" max:"100" help:"resolution of the screen"`
+	// TournamentAgentURLs are the URLs of the agents competing.
+	TournamentAgentURLs []string `env:"TOURNAMENT_AGENT_URLS" min:"2" url:"http,https,ws,wss" help:"comma-separated URLs of the agents competing"`
+	// TournamentOrder is the order the agents take turns in.
+	TournamentOrder order `env:"TOURNAMENT_ORDER" default:"random" enum:"round_robin,random" help:"order the agents take turns in"`
+	// TournamentReportFile is the file to write the JSON report to.
+	TournamentReportFile string `env:"TOURNAMENT_REPORT_FILE" default:"assets/tournament.json" help:"file to write the JSON report to"`
+	// TournamentSeed is the seed of the random order and the bootstrap (0 picks one).
+	TournamentSeed uint64 `env:"TOURNAMENT_SEED" default:"0" help:"seed of the random order and the bootstrap (0 picks one)"`
+}
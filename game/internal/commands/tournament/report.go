@@ -0,0 +1,151 @@
+package tournament
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/eval"
+	"github.com/nizarmah/stig/game/internal/game"
+	"github.com/nizarmah/stig/game/internal/stats"
+)
+
+const (
+	// confidence is the confidence of the intervals of the mean lap times.
+	confidence = 0.95
+	// resamples is the number of bootstrap resamples of the lap times.
+	resamples = 10000
+)
+
+// report is the ranking of the agents of a tournament.
+type report struct {
+	// Confidence is the confidence of the intervals.
+	Confidence float64 `json:"confidence"`
+	// Interrupted is whether the tournament was stopped before all its laps were played.
+	// The standings then only cover the laps played so far.
+	Interrupted bool `json:"interrupted"`
+	// Order is the order the agents took turns in.
+	Order order `json:"order"`
+	// Seed is the seed of the random order and the bootstrap.
+	Seed uint64 `json:"seed"`
+	// Standings are the agents, from best to worst.
+	Standings []standing `json:"standings"`
+}
+
+// standing is the rank of an agent in the tournament.
+type standing struct {
+	// MeanLapTimeCI is the confidence interval of the mean lap time, as [low, high].
+	MeanLapTimeCI [2]time.Duration `json:"mean_lap_time_ci"`
+	// Rank is the rank of the agent, starting at 1.
+	Rank int `json:"rank"`
+	// Report is the evaluation of the agent.
+	Report eval.Report `json:"report"`
+	// URL is the URL of the agent.
+	URL string `json:"url"`
+	// VersusNext compares the lap times with the next agent in the ranking, if any.
+	VersusNext *comparison `json:"versus_next,omitempty"`
+}
+
+// comparison is a Mann-Whitney U test of the lap times of two agents.
+type comparison struct {
+	// P is the two-sided p-value that the lap times differ.
+	P float64 `json:"p"`
+	// U is the U statistic of the better agent.
+	U float64 `json:"u"`
+	// URL is the URL of the other agent.
+	URL string `json:"url"`
+}
+
+// newReport ranks the agents by completion rate, then by mean lap time.
+func newReport(o order, seed uint64, urls []string, reports []eval.Report, rng *rand.Rand) report {
+	standings := make([]standing, len(reports))
+	for i, agentReport := range reports {
+		low, high := stats.BootstrapMean(lapTimes(agentReport), confidence, resamples, rng)
+
+		standings[i] = standing{
+			MeanLapTimeCI: [2]time.Duration{low, high},
+			Report:        agentReport,
+			URL:           urls[i],
+		}
+	}
+
+	slices.SortStableFunc(standings, func(a, b standing) int {
+		if c := cmp.Compare(b.Report.CompletionRate, a.Report.CompletionRate); c != 0 {
+			return c
+		}
+
+		return cmp.Compare(a.Report.LapTime.Mean, b.Report.LapTime.Mean)
+	})
+
+	for i := range standings {
+		standings[i].Rank = i + 1
+
+		if i+1 < len(standings) {
+			next := standings[i+1]
+			u, p := stats.MannWhitney(lapTimes(standings[i].Report), lapTimes(next.Report))
+
+			standings[i].VersusNext = &comparison{
+				P:   p,
+				U:   u,
+				URL: next.URL,
+			}
+		}
+	}
+
+	return report{
+		Confidence: confidence,
+		Order:      o,
+		Seed:       seed,
+		Standings:  standings,
+	}
+}
+
+// writeTable writes the ranking as a readable table.
+func (r report) writeTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "rank\tagent\turl\tfinished\tbest\tmean\t%.0f%% ci\tp vs next\n", 100*r.Confidence)
+	for _, s := range r.Standings {
+		versusNext := "-"
+		if s.VersusNext != nil {
+			versusNext = fmt.Sprintf("%.3f", s.VersusNext.P)
+		}
+
+		fmt.Fprintf(
+			tw,
+			"%d\t%s\t%s\t%d/%d\t%s\t%s\t[%s, %s]\t%s\n",
+			s.Rank,
+			s.Report.Agent.Model,
+			s.URL,
+			s.Report.Finished,
+			s.Report.Laps,
+			s.Report.LapTime.Min,
+			s.Report.LapTime.Mean.Round(time.Millisecond),
+			s.MeanLapTimeCI[0].Round(time.Millisecond),
+			s.MeanLapTimeCI[1].Round(time.Millisecond),
+			versusNext,
+		)
+	}
+
+	if r.Interrupted {
+		fmt.Fprintf(tw, "\ninterrupted: the standings only cover the laps played so far\n")
+	}
+
+	return tw.Flush()
+}
+
+// lapTimes returns the lap times of the finished laps of a report.
+func lapTimes(r eval.Report) []time.Duration {
+	times := []time.Duration{}
+	for _, result := range r.Results {
+		if result.Status == game.LapFinished {
+			times = append(times, result.LapTime)
+		}
+	}
+
+	return times
+}
@@ -0,0 +1,35 @@
+package tournament
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/agent"
+	"github.com/nizarmah/stig/game/internal/eval"
+	"github.com/nizarmah/stig/game/internal/game"
+)
+
+func TestWriteTableInterrupted(t *testing.T) {
+	reports := []eval.Report{
+		eval.NewReport(agent.Info{}, []eval.Lap{
+			{Result: game.LapResult{LapTime: time.Minute, Status: game.LapFinished}},
+		}),
+	}
+
+	for _, interrupted := range []bool{false, true} {
+		r := newReport(orderRoundRobin, 1, []string{"http://a"}, reports, rand.New(rand.NewPCG(1, 1)))
+		r.Interrupted = interrupted
+
+		buf := bytes.Buffer{}
+		if err := r.writeTable(&buf); err != nil {
+			t.Fatalf("writeTable() error = %v", err)
+		}
+
+		if got := strings.Contains(buf.String(), "interrupted"); got != interrupted {
+			t.Errorf("writeTable() of interrupted=%t mentions the interruption: %t\n%s", interrupted, got, buf.String())
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package tournament
+
+import (
+	"math/rand/v2"
+)
+
+// order is the order the agents take turns in.
+type order = string
+
+const (
+	// orderRoundRobin takes turns in the order of the agents.
+	orderRoundRobin order = "round_robin"
+	// orderRandom takes turns in a random order, reshuffled every round.
+	orderRandom order = "random"
+)
+
+// turn is a lap played by an agent.
+type turn struct {
+	// agent is the index of the agent.
+	agent int
+	// lap is the index of the lap of the agent.
+	lap int
+}
+
+// schedule interleaves the laps of the agents, one lap each per round,
+// so drift in the browser load spreads evenly across them.
+func schedule(o order, agents int, laps int, rng *rand.Rand) []turn {
+	turns := make([]turn, 0, agents*laps)
+	for lap := range laps {
+		round := make([]turn, agents)
+		for agent := range agents {
+			round[agent] = turn{agent: agent, lap: lap}
+		}
+
+		if o == orderRandom {
+			rng.Shuffle(len(round), func(i, j int) {
+				round[i], round[j] = round[j], round[i]
+			})
+		}
+
+		turns = append(turns, round...)
+	}
+
+	return turns
+}
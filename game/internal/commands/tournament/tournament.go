@@ -0,0 +1,200 @@
+// Package tournament ranks agents by interleaving their laps on the same game.
+package tournament
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"os"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/agent"
+	"github.com/nizarmah/stig/game/internal/controller"
+	"github.com/nizarmah/stig/game/internal/driver"
+	"github.com/nizarmah/stig/game/internal/eval"
+	"github.com/nizarmah/stig/game/internal/game"
+	"github.com/nizarmah/stig/game/internal/screen"
+)
+
+// contender is an agent competing in the tournament.
+type contender struct {
+	// agentClient is the agent the driver acts through.
+	agentClient *agent.Fallback
+	// agentInfo is the description of the agent.
+	agentInfo agent.Info
+	// driverConfig is the configuration of the driver of each lap.
+	driverConfig driver.Configuration
+	// laps are the laps played by the agent.
+	laps []eval.Lap
+	// url is the URL of the agent.
+	url string
+}
+
+// errInterrupted is returned when the tournament is stopped before all its laps were played.
+var errInterrupted = errors.New("interrupted")
+
+// Run plays the tournament and ranks the agents.
+// When stopped early, the laps played so far are still ranked, and the tournament fails.
+func Run(ctx context.Context, env *Env) {
+	seed := env.TournamentSeed
+	if seed == 0 {
+		seed = uint64(time.Now().UnixNano())
+	}
+
+	rng := rand.New(rand.NewPCG(seed, seed))
+
+	// Play the laps.
+	contenders, playErr := play(ctx, env, rng)
+	if playErr != nil && !errors.Is(playErr, errInterrupted) {
+		log.Fatalf("failed to play tournament: %v", playErr)
+	}
+
+	interrupted := playErr != nil
+
+	// Rank the contenders.
+	urls := make([]string, 0, len(contenders))
+	reports := make([]eval.Report, 0, len(contenders))
+	for _, c := range contenders {
+		urls = append(urls, c.url)
+		reports = append(reports, eval.NewReport(c.agentInfo, c.laps))
+	}
+
+	tournament := newReport(env.TournamentOrder, seed, urls, reports, rng)
+	tournament.Interrupted = interrupted
+
+	data, err := json.MarshalIndent(tournament, "", "  ")
+	if err != nil {
+		log.Fatalf("failed to marshal report: %v", err)
+	}
+
+	if err := os.WriteFile(env.TournamentReportFile, data, 0644); err != nil {
+		log.Fatalf("failed to write report: %v", err)
+	}
+
+	if err := tournament.writeTable(os.Stdout); err != nil {
+		log.Fatalf("failed to print report: %v", err)
+	}
+
+	if interrupted {
+		log.Fatalf("tournament %v", playErr)
+	}
+}
+
+// play plays the laps of the tournament, interleaved across the agents, and returns the agents with their laps.
+func play(ctx context.Context, env *Env, rng *rand.Rand) ([]*contender, error) {
+	// Create the game client.
+	gameClient, err := game.NewClient(
+		ctx,
+		game.ClientConfig{
+			BrowserBin:      env.BrowserBin,
+			BrowserHeadless: env.BrowserHeadless,
+			BrowserWSURL:    env.BrowserWSURL,
+			Debug:           env.GameDebug,
+			FPS:             env.FramesPerSecond,
+			GameURL:         env.GameURL,
+			WindowHeight:    env.WindowHeight,
+			WindowWidth:     env.WindowWidth,
+		},
+		env.GameTimeout,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create game client: %w", err)
+	}
+	defer gameClient.Close()
+
+	// Create the controller client.
+	controllerClient := controller.NewClient(gameClient.Page)
+
+	// Create the screen client.
+	screenClient := screen.NewClient(screen.ClientConfiguration{
+		Debug:        env.ScreenDebug,
+		Page:         gameClient.Page,
+		Resolution:   env.ScreenResolution,
+		WindowHeight: env.WindowHeight,
+		WindowWidth:  env.WindowWidth,
+	})
+
+	// Create the contenders.
+	contenders := make([]*contender, 0, len(env.TournamentAgentURLs))
+	for _, url := range env.TournamentAgentURLs {
+		baseAgent, err := agent.New(agent.ClientConfiguration{
+			APIURL:  url,
+			Debug:   env.AgentDebug,
+			Timeout: env.AgentTimeout,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create agent %s: %w", url, err)
+		}
+		defer agent.Close(baseAgent)
+
+		agentInfo, err := agent.Handshake(ctx, baseAgent, agent.Frame{Height: env.WindowHeight, Width: env.WindowWidth})
+		if err != nil {
+			return nil, fmt.Errorf("failed to handshake with agent %s: %w", url, err)
+		}
+
+		log.Println(fmt.Sprintf("agent %s: %s", url, agentInfo))
+
+		agentClient := agent.NewFallback(agent.FallbackConfiguration{
+			Agent:  baseAgent,
+			Debug:  env.AgentDebug,
+			Policy: env.AgentFallback,
+		})
+
+		contenders = append(contenders, &contender{
+			agentClient: agentClient,
+			agentInfo:   agentInfo,
+			driverConfig: driver.Configuration{
+				Agent:      agentClient,
+				Controller: controllerClient,
+				Debug:      env.AgentDebug,
+				Depth:      env.PipelineDepth,
+				Screen:     screenClient,
+			},
+			url: url,
+		})
+	}
+
+	// Play the laps, interleaved across the contenders.
+	turns := schedule(env.TournamentOrder, len(contenders), env.LapsNum, rng)
+	for i, t := range turns {
+		if ctx.Err() != nil {
+			return contenders, interrupted(ctx, i, len(turns))
+		}
+
+		c := contenders[t.agent]
+
+		played, err := eval.PlayLap(ctx, gameClient, c.driverConfig, c.agentClient, env.LapTimeout)
+
+		// A lap cut short by the interruption says nothing about the agent.
+		if ctx.Err() != nil {
+			return contenders, interrupted(ctx, i, len(turns))
+		}
+
+		if err != nil {
+			log.Println(fmt.Sprintf("failed to play lap %d of %s: %v", t.lap, c.url, err))
+		}
+
+		played.Result.Lap = t.lap
+		log.Println(fmt.Sprintf(
+			"lap %d/%d: %s lap %d: %s, time: %s",
+			i+1,
+			len(turns),
+			c.url,
+			t.lap,
+			played.Result.Status,
+			played.Result.LapTime,
+		))
+
+		c.laps = append(c.laps, played)
+	}
+
+	return contenders, nil
+}
+
+// interrupted returns the error of a tournament stopped after the given number of laps.
+func interrupted(ctx context.Context, played int, laps int) error {
+	return fmt.Errorf("%w after %d of %d laps: %w", errInterrupted, played, laps, ctx.Err())
+}
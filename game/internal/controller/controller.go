@@ -2,6 +2,8 @@
 package controller
 
 import (
+	"sync"
+
 	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/input"
 
@@ -16,6 +18,9 @@ var (
 type Client struct {
 	// Page is the page of the game.
 	page *rod.Page
+
+	// mu guards the fields below.
+	mu sync.Mutex
 	// Action is the last action done by the controller.
 	action game.Action
 }
@@ -30,6 +35,9 @@ func NewClient(page *rod.Page) *Client {
 
 // Apply applies an action in the game.
 func (c *Client) Apply(action game.Action) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	if err := c.applyKey(
 		mapInputKey(game.ThrottleStateMap, c.action.Throttle),
 		mapInputKey(game.ThrottleStateMap, action.Throttle),
@@ -37,6 +45,8 @@ func (c *Client) Apply(action game.Action) error {
 		return err
 	}
 
+	c.action.Throttle = action.Throttle
+
 	if err := c.applyKey(
 		mapInputKey(game.SteeringStateMap, c.action.Steering),
 		mapInputKey(game.SteeringStateMap, action.Steering),
@@ -44,9 +54,45 @@ func (c *Client) Apply(action game.Action) error {
 		return err
 	}
 
+	c.action.Steering = action.Steering
+
 	return nil
 }
 
+// Held returns the keys the client is holding down.
+func (c *Client) Held() []input.Key {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := []input.Key{}
+	for _, key := range []input.Key{
+		mapInputKey(game.ThrottleStateMap, c.action.Throttle),
+		mapInputKey(game.SteeringStateMap, c.action.Steering),
+	} {
+		if key != keyNil {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// Keys returns the keys the client presses to apply actions.
+// Human input on other keys can be told apart from the client's.
+func Keys() []input.Key {
+	keys := make([]input.Key, 0, len(game.ThrottleStateMap)+len(game.SteeringStateMap))
+	for _, stateMap := range []map[string][]input.Key{
+		game.ThrottleStateMap,
+		game.SteeringStateMap,
+	} {
+		for state := range stateMap {
+			keys = append(keys, mapInputKey(stateMap, state))
+		}
+	}
+
+	return keys
+}
+
 // ApplyKey applies a key action to the game.
 func (c *Client) applyKey(prev, curr input.Key) error {
 	// If the key didn't change, do nothing.
@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/proto"
+
+	"github.com/nizarmah/stig/game/internal/game"
+)
+
+// heldKeysJS tracks the keys held on the page.
+const heldKeysJS = `() => {
+	window.heldKeys = {}
+	window.addEventListener('keydown', (e) => window.heldKeys[e.code] = true)
+	window.addEventListener('keyup', (e) => delete window.heldKeys[e.code])
+}`
+
+// newPage opens a blank page in a headless browser, tracking the keys held on it.
+// The test is skipped when no browser is found.
+func newPage(t *testing.T) *rod.Page {
+	t.Helper()
+
+	bin := os.Getenv("BROWSER_BIN")
+	if bin == "" {
+		var ok bool
+		if bin, ok = launcher.LookPath(); !ok {
+			t.Skip("no browser found")
+		}
+	}
+
+	browserLauncher := launcher.New().Bin(bin).Headless(true)
+	t.Cleanup(browserLauncher.Cleanup)
+
+	controlURL, err := browserLauncher.Launch()
+	if err != nil {
+		t.Fatalf("failed to launch browser: %v", err)
+	}
+
+	browser := rod.New().ControlURL(controlURL)
+	if err := browser.Connect(); err != nil {
+		t.Fatalf("failed to connect to browser: %v", err)
+	}
+	t.Cleanup(func() { browser.Close() })
+
+	page, err := browser.Page(proto.TargetCreateTarget{URL: "about:blank"})
+	if err != nil {
+		t.Fatalf("failed to open page: %v", err)
+	}
+
+	if _, err := page.Evaluate(&rod.EvalOptions{JS: heldKeysJS}); err != nil {
+		t.Fatalf("failed to track held keys: %v", err)
+	}
+
+	return page
+}
+
+// heldKeys returns the codes of the keys held on the page, sorted.
+func heldKeys(t *testing.T, page *rod.Page) []string {
+	t.Helper()
+
+	res, err := page.Evaluate(&rod.EvalOptions{JS: `() => Object.keys(window.heldKeys)`, ByValue: true})
+	if err != nil {
+		t.Fatalf("failed to read held keys: %v", err)
+	}
+
+	codes := []string{}
+	for _, code := range res.Value.Arr() {
+		codes = append(codes, code.Str())
+	}
+	slices.Sort(codes)
+
+	return codes
+}
+
+func TestClientApplyReleasesPreviousKeys(t *testing.T) {
+	page := newPage(t)
+	client := NewClient(page)
+
+	steps := []struct {
+		action game.Action
+		want   []string
+	}{
+		{
+			action: game.Action{Throttle: game.ThrottleAccelerate, Steering: game.SteeringLeft},
+			want:   []string{"ArrowLeft", "ArrowUp"},
+		},
+		{
+			action: game.Action{Throttle: game.ThrottleBrake, Steering: game.SteeringLeft},
+			want:   []string{"ArrowDown", "ArrowLeft"},
+		},
+		{
+			action: game.Action{Throttle: game.ThrottleBrake, Steering: game.SteeringRight},
+			want:   []string{"ArrowDown", "ArrowRight"},
+		},
+		{
+			action: game.Action{},
+			want:   []string{},
+		},
+	}
+
+	for _, step := range steps {
+		if err := client.Apply(step.action); err != nil {
+			t.Fatalf("failed to apply %+v: %v", step.action, err)
+		}
+
+		if got := heldKeys(t, page); !slices.Equal(got, step.want) {
+			t.Errorf("after applying %+v, held keys = %v, want %v", step.action, got, step.want)
+		}
+	}
+}
@@ -5,27 +5,139 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"strings"
+	"math"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/input"
 	"github.com/nizarmah/stig/game/internal/game"
+	"github.com/ysmood/gson"
 )
 
+const (
+	// keyBinding is the function the page calls on every key event.
+	keyBinding = "__stigKey"
+	// clockSamples is the number of times the clock of the page is read to estimate its offset.
+	clockSamples = 5
+)
+
+// keyListenerJS pushes the key events of the watched keys to Go, as they happen.
+// The events are timed on the performance.now() clock, along with its origin on the wall clock.
+const keyListenerJS = `() => {
+	if (window.__stigKeyListener) return
+
+	const watchedCodes = JSON.parse(%q)
+
+	window.__stigKeyListener = (e) => {
+		// skip the keys we don't watch, and the repeats of held keys.
+		if (!watchedCodes.includes(e.code) || e.repeat) return
+
+		window.%s({
+			code: e.code,
+			origin: performance.timeOrigin,
+			time: e.timeStamp,
+			type: e.type,
+		})
+	}
+
+	window.addEventListener('keydown', window.__stigKeyListener)
+	window.addEventListener('keyup', window.__stigKeyListener)
+}`
+
+// clockJS reads the clock the key events are timed on, as milliseconds since the epoch.
+const clockJS = `() => performance.timeOrigin + performance.now()`
+
+// KeyEventType is the type of a key event.
+type KeyEventType = string
+
+const (
+	// KeyDown is the type of a key press.
+	KeyDown KeyEventType = "keydown"
+	// KeyUp is the type of a key release.
+	KeyUp KeyEventType = "keyup"
+)
+
+// KeyEvent is a key event pushed by the page, as a point of the input timeline.
+type KeyEvent struct {
+	// Action is the action held right after the event.
+	Action game.Action `json:"action"`
+	// Code is the code of the key.
+	Code string `json:"code"`
+	// PageTime is when the event happened on the performance.now() clock of the page (milliseconds).
+	PageTime float64 `json:"page_time_ms"`
+	// Time is when the event happened on the clock of the process,
+	// estimated from the offset of the clock of the page when the timeline started.
+	Time time.Time `json:"time"`
+	// Type is KeyDown or KeyUp.
+	Type KeyEventType `json:"type"`
+}
+
+// Timeline is the input timeline of a lap: the key events pushed by the page, in order.
+type Timeline struct {
+	// ClockOffset is how far the clock of the page was ahead of the clock of the process.
+	ClockOffset time.Duration
+	// ClockUncertainty is half the round trip of the read of the clock of the page the offset is from.
+	ClockUncertainty time.Duration
+	// Events are the key events, in order.
+	Events []KeyEvent
+	// Initial is the action held when the timeline started.
+	Initial game.Action
+}
+
+// ActionAt returns the action held at a time on the clock of the process.
+// Times before the first event resolve to the action held when the timeline started.
+func (t Timeline) ActionAt(at time.Time) game.Action {
+	// Find the first event after the time; the one before it set the held action.
+	i := sort.Search(len(t.Events), func(i int) bool {
+		return t.Events[i].Time.After(at)
+	})
+	if i == 0 {
+		return t.Initial
+	}
+
+	return t.Events[i-1].Action
+}
+
 // WatcherConfiguration is the configuration for the watcher.
 type WatcherConfiguration struct {
 	// Debug is whether to print debug information.
 	Debug bool
+	// IgnoreKeys are the keys the watcher ignores, such as the ones pressed by the controller client.
+	IgnoreKeys []input.Key
 	// Page is the page of the game.
 	Page *rod.Page
 }
 
 // Watcher is a watcher for controller actions.
+// The page pushes every key event, so presses shorter than a tick are kept in the input timeline.
 type Watcher struct {
 	// debug is whether to print debug information.
 	debug bool
 	// page is the page of the game.
 	page *rod.Page
+	// steeringKeys maps the watched key codes to their steering state.
+	steeringKeys map[string]game.Steering
+	// stopListening stops the page from pushing its key events, once the listener is added.
+	stopListening func() error
+	// throttleKeys maps the watched key codes to their throttle state.
+	throttleKeys map[string]game.Throttle
+
+	// mu guards the fields below.
+	mu sync.Mutex
+	// action is the action currently held.
+	action game.Action
+	// activeSteering is the order each held steering state was last pressed in.
+	activeSteering map[game.Steering]uint64
+	// activeThrottle is the order each held throttle state was last pressed in.
+	activeThrottle map[game.Throttle]uint64
+	// events is the number of key events observed, ordering the presses.
+	events uint64
+	// recording is whether the key events are added to the timeline.
+	recording bool
+	// timeline is the timeline being recorded.
+	timeline Timeline
 }
 
 // NewWatcher creates a new watcher.
@@ -33,39 +145,46 @@ func NewWatcher(
 	ctx context.Context,
 	cfg WatcherConfiguration,
 ) (*Watcher, error) {
-	if err := addKeyListener(ctx, cfg.Page, cfg.Debug); err != nil {
+	ignored := make(map[string]bool, len(cfg.IgnoreKeys))
+	for _, key := range cfg.IgnoreKeys {
+		ignored[key.Info().Code] = true
+	}
+
+	w := &Watcher{
+		debug:          cfg.Debug,
+		page:           cfg.Page,
+		steeringKeys:   createKeyMap(game.SteeringStateMap, ignored),
+		throttleKeys:   createKeyMap(game.ThrottleStateMap, ignored),
+		activeSteering: make(map[game.Steering]uint64),
+		activeThrottle: make(map[game.Throttle]uint64),
+	}
+
+	if err := w.addKeyListener(ctx); err != nil {
+		w.Close()
 		return nil, fmt.Errorf("failed to add key listener: %w", err)
 	}
 
-	return &Watcher{
-		debug: cfg.Debug,
-		page:  cfg.Page,
-	}, nil
+	return w, nil
 }
 
-// Peek returns the last action from the window.
-func (w *Watcher) Peek() (game.Action, error) {
-	action := game.Action{}
-
-	// Get the last action from the window.
-	res, err := w.page.Evaluate(&rod.EvalOptions{
-		JS:      `() => window.lastAction`,
-		ByValue: true,
-	})
-	if err != nil {
-		return action, err
+// Close stops the page from pushing its key events to the watcher.
+func (w *Watcher) Close() {
+	if w.stopListening == nil {
+		return
 	}
 
-	// Marshal the result to JSON.
-	actionJSON, err := res.Value.MarshalJSON()
-	if err != nil {
-		return action, fmt.Errorf("failed to marshal action: %w", err)
+	if err := w.stopListening(); err != nil {
+		log.Println(fmt.Sprintf("failed to stop listening to keys: %v", err))
 	}
 
-	// Unmarshal the JSON string into the action.
-	if err := json.Unmarshal(actionJSON, &action); err != nil {
-		return action, fmt.Errorf("failed to unmarshal action: %w", err)
-	}
+	w.stopListening = nil
+}
+
+// Peek returns the action currently held.
+func (w *Watcher) Peek() (game.Action, error) {
+	w.mu.Lock()
+	action := w.action
+	w.mu.Unlock()
 
 	if w.debug {
 		log.Println(
@@ -80,152 +199,212 @@ func (w *Watcher) Peek() (game.Action, error) {
 	return action, nil
 }
 
-// addKeyListener adds a key listener to the page.
-func addKeyListener(
-	ctx context.Context,
-	page *rod.Page,
-	debug bool,
-) error {
-	// Create the throttle key map.
-	throttleKeyMapJSON, err := createKeyMapJSON(game.ThrottleStateMap)
+// StartTimeline starts recording a new input timeline from the action currently held,
+// such as at the start of a lap.
+// The key events are only kept while a timeline is recorded.
+func (w *Watcher) StartTimeline(ctx context.Context) error {
+	offset, uncertainty, err := w.clockOffset(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create throttle key map: %w", err)
+		return fmt.Errorf("failed to estimate page clock offset: %w", err)
 	}
 
-	// Create the steering key map.
-	steeringKeyMapJSON, err := createKeyMapJSON(game.SteeringStateMap)
-	if err != nil {
-		return fmt.Errorf("failed to create steering key map: %w", err)
-	}
-
-	// Create a keyboard event listener.
-	listener := fmt.Sprintf(
-		`
-			() => {
-				const throttleNeutral = %q
-				const throttleKeyMap = JSON.parse(%q)
-
-				const steeringNeutral = %q
-				const steeringKeyMap = JSON.parse(%q)
-
-				// we might have "accelerate" active,
-				// then press "brake", without releasing "accelerate".
-				// so we track if a state is active and when it was last pressed.
-				// so if we release "brake" we go back to the last active state.
-				const activeThrottleStates = {}
-				Object.values(activeThrottleStates).forEach(
-					(state) => activeThrottleStates[state] = null
-				)
-
-				// do the same for steering.
-				const activeSteeringStates = {}
-				Object.values(activeSteeringStates).forEach(
-					(state) => activeSteeringStates[state] = null
-				)
-
-				// we want to easily return the last action upon request,
-				// so we track it in a global variable.
-				window.lastAction = {
-					throttle: throttleNeutral,
-					steering: steeringNeutral,
-				}
-
-				// whenever we get a key event, we update the action.
-				const updateAction = (
-					action,
-					neutralState,
-					activeStates,
-					keyMap,
-					code,
-					type
-				) => {
-					const state = keyMap[code]
-					if (!state) return
-
-					// update the active states.
-					activeStates[state] = Date.now()
-					if (type === 'keyup') delete activeStates[state]
-
-					// if no state is active, we return the neutral state.
-					if (Object.keys(activeStates).length === 0) {
-						window.lastAction[action] = neutralState
-						return
-					}
-
-					// sort the active states by the most recent.
-					const sortedActiveStates = Object
-						.entries(activeStates)
-						.sort((a, b) => b[1] - a[1])
-
-					// update the last action with the most recent active state.
-					window.lastAction[action] = sortedActiveStates[0][0]
-				}
-
-				const handler = (e) => {
-					updateAction(
-						"throttle",
-						throttleNeutral,
-						activeThrottleStates,
-						throttleKeyMap,
-						e.code,
-						e.type
-					)
-
-					updateAction(
-						"steering",
-						steeringNeutral,
-						activeSteeringStates,
-						steeringKeyMap,
-						e.code,
-						e.type
-					)
-				}
-
-				window.addEventListener('keydown', handler)
-				window.addEventListener('keyup', handler)
-			}
-		`,
-		game.ThrottleNeutral,
-		throttleKeyMapJSON,
-		game.SteeringStraight,
-		steeringKeyMapJSON,
-	)
-
-	// Add the listener to the page.
-	if _, err := page.
-		Context(ctx).
-		Evaluate(&rod.EvalOptions{JS: listener}); err != nil {
-		if debug {
-			log.Println(fmt.Sprintf("failed to add listener to page: %v", err))
+	if w.debug {
+		log.Println(fmt.Sprintf("page clock offset: %s ± %s", offset, uncertainty))
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.recording = true
+	w.timeline = Timeline{
+		ClockOffset:      offset,
+		ClockUncertainty: uncertainty,
+		Initial:          w.action,
+	}
+
+	return nil
+}
+
+// StopTimeline stops recording the input timeline, and returns it.
+func (w *Watcher) StopTimeline() Timeline {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	timeline := w.timeline
+
+	w.recording = false
+	w.timeline = Timeline{}
+
+	return timeline
+}
+
+// clockOffset estimates how far the clock of the page is ahead of the clock of the process,
+// from the read of the clock of the page with the shortest round trip.
+// It returns the offset along with half that round trip, bounding its error.
+func (w *Watcher) clockOffset(ctx context.Context) (time.Duration, time.Duration, error) {
+	var offset time.Duration
+	roundTrip := time.Duration(math.MaxInt64)
+
+	for range clockSamples {
+		before := time.Now()
+		res, err := w.page.Context(ctx).Evaluate(&rod.EvalOptions{
+			JS:      clockJS,
+			ByValue: true,
+		})
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to read page clock: %w", err)
 		}
 
-		return fmt.Errorf("failed to add listener to page: %w", err)
+		after := time.Now()
+
+		if rt := after.Sub(before); rt < roundTrip {
+			roundTrip = rt
+			offset = epochMillis(res.Value.Num()).Sub(before.Add(rt / 2))
+		}
 	}
 
-	if debug {
-		log.Println(fmt.Sprintf("added key listener: %s", strings.TrimSpace(listener)))
+	return offset, roundTrip / 2, nil
+}
+
+// addKeyListener makes the page push its key events to the watcher.
+func (w *Watcher) addKeyListener(ctx context.Context) error {
+	stop, err := w.page.Expose(keyBinding, func(event gson.JSON) (interface{}, error) {
+		w.observe(event)
+		return nil, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to expose key binding: %w", err)
+	}
+
+	w.stopListening = stop
+
+	// Create the list of watched key codes.
+	watchedCodes := make([]string, 0, len(w.throttleKeys)+len(w.steeringKeys))
+	for code := range w.throttleKeys {
+		watchedCodes = append(watchedCodes, code)
+	}
+
+	for code := range w.steeringKeys {
+		watchedCodes = append(watchedCodes, code)
+	}
+
+	watchedCodesJSON, err := json.Marshal(watchedCodes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal watched keys: %w", err)
+	}
+
+	listener := fmt.Sprintf(keyListenerJS, string(watchedCodesJSON), keyBinding)
+
+	// Keep listening after the page reloads.
+	if _, err := w.page.EvalOnNewDocument(fmt.Sprintf("(%s)()", listener)); err != nil {
+		return fmt.Errorf("failed to add key listener on new documents: %w", err)
+	}
+
+	if _, err := w.page.Context(ctx).Evaluate(&rod.EvalOptions{JS: listener}); err != nil {
+		return fmt.Errorf("failed to add key listener to page: %w", err)
+	}
+
+	if w.debug {
+		log.Println(fmt.Sprintf("added key listener for %s", watchedCodesJSON))
 	}
 
 	return nil
 }
 
-// createThrottleKeyMapJSON creates a JSON string for the throttle key map.
-func createKeyMapJSON(
+// observe updates the held action with a key event pushed by the page,
+// and adds it to the timeline while one is recorded.
+func (w *Watcher) observe(payload gson.JSON) {
+	pageTime := payload.Get("time").Num()
+
+	event := KeyEvent{
+		Code:     payload.Get("code").Str(),
+		PageTime: pageTime,
+		Type:     payload.Get("type").Str(),
+	}
+
+	w.mu.Lock()
+
+	w.events++
+	event.Time = epochMillis(payload.Get("origin").Num() + pageTime)
+
+	// We might have "accelerate" held, then press "brake" without releasing "accelerate".
+	// So we track the order each held state was last pressed in, and fall back to the most recent one.
+	if state, ok := w.throttleKeys[event.Code]; ok {
+		w.action.Throttle = updateActive(w.activeThrottle, state, event.Type, w.events, game.ThrottleNeutral)
+	}
+
+	if state, ok := w.steeringKeys[event.Code]; ok {
+		w.action.Steering = updateActive(w.activeSteering, state, event.Type, w.events, game.SteeringStraight)
+	}
+
+	event.Action = w.action
+
+	// The clock offset is only measured once a timeline starts, so the events are only moved onto
+	// the clock of the process while recording.
+	if w.recording {
+		event.Time = event.Time.Add(-w.timeline.ClockOffset)
+		w.timeline.Events = append(w.timeline.Events, event)
+	}
+
+	w.mu.Unlock()
+
+	if w.debug {
+		log.Println(
+			fmt.Sprintf(
+				"key event: %s %s at %.3fms: throttle: %q, steering: %q",
+				event.Type,
+				event.Code,
+				event.PageTime,
+				event.Action.Throttle,
+				event.Action.Steering,
+			),
+		)
+	}
+}
+
+// updateActive updates the held states with a key event,
+// and returns the most recently pressed one, or the neutral state if none is held.
+func updateActive(
+	active map[string]uint64,
+	state string,
+	eventType KeyEventType,
+	order uint64,
+	neutral string,
+) string {
+	if eventType == KeyUp {
+		delete(active, state)
+	} else {
+		active[state] = order
+	}
+
+	latest, latestOrder := neutral, uint64(0)
+	for s, pressed := range active {
+		if pressed > latestOrder {
+			latest, latestOrder = s, pressed
+		}
+	}
+
+	return latest
+}
+
+// epochMillis returns the time of milliseconds since the epoch, to the microsecond.
+func epochMillis(ms float64) time.Time {
+	return time.UnixMicro(int64(math.Round(ms * 1000)))
+}
+
+// createKeyMap maps the key codes of the states to their state, leaving out the ignored ones.
+func createKeyMap(
 	stateMap map[string][]input.Key,
-) (string, error) {
-	// Create the key map as [keyCode]: state.
+	ignored map[string]bool,
+) map[string]string {
 	keyMap := make(map[string]string)
 	for state, keys := range stateMap {
 		for _, key := range keys {
-			keyMap[key.Info().Code] = state
+			if code := key.Info().Code; !ignored[code] {
+				keyMap[code] = state
+			}
 		}
 	}
 
-	// Marshal the key map to JSON.
-	mapJSON, err := json.Marshal(keyMap)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal key map: %w", err)
-	}
-
-	return string(mapJSON), nil
+	return keyMap
 }
@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ysmood/gson"
+
+	"github.com/nizarmah/stig/game/internal/game"
+)
+
+// newTestWatcher creates a watcher without a page, observing the pushed key events directly.
+func newTestWatcher() *Watcher {
+	return &Watcher{
+		steeringKeys:   createKeyMap(game.SteeringStateMap, nil),
+		throttleKeys:   createKeyMap(game.ThrottleStateMap, nil),
+		activeSteering: make(map[game.Steering]uint64),
+		activeThrottle: make(map[game.Throttle]uint64),
+	}
+}
+
+// push observes a key event as the page would push it, at milliseconds since the origin of the page.
+func push(w *Watcher, eventType KeyEventType, code string, ms float64) {
+	w.observe(gson.New(map[string]any{
+		"code":   code,
+		"origin": 1_700_000_000_000.0,
+		"time":   ms,
+		"type":   eventType,
+	}))
+}
+
+// at returns the time of milliseconds since the origin of the page, on the clock of the process.
+func at(ms float64, offset time.Duration) time.Time {
+	return epochMillis(1_700_000_000_000.0 + ms).Add(-offset)
+}
+
+func TestWatcherHeldAction(t *testing.T) {
+	w := newTestWatcher()
+
+	steps := []struct {
+		eventType KeyEventType
+		code      string
+		want      game.Action
+	}{
+		{KeyDown, "KeyW", game.Action{Throttle: game.ThrottleAccelerate}},
+		{KeyDown, "KeyS", game.Action{Throttle: game.ThrottleBrake}},
+		{KeyDown, "KeyA", game.Action{Throttle: game.ThrottleBrake, Steering: game.SteeringLeft}},
+		{KeyUp, "KeyS", game.Action{Throttle: game.ThrottleAccelerate, Steering: game.SteeringLeft}},
+		{KeyDown, "KeyD", game.Action{Throttle: game.ThrottleAccelerate, Steering: game.SteeringRight}},
+		{KeyUp, "KeyD", game.Action{Throttle: game.ThrottleAccelerate, Steering: game.SteeringLeft}},
+		{KeyUp, "KeyW", game.Action{Steering: game.SteeringLeft}},
+		{KeyUp, "KeyA", game.Action{}},
+	}
+
+	for i, step := range steps {
+		push(w, step.eventType, step.code, float64(i))
+
+		if got, _ := w.Peek(); got != step.want {
+			t.Errorf("after %s %s, Peek() = %+v, want %+v", step.eventType, step.code, got, step.want)
+		}
+	}
+}
+
+func TestWatcherTimeline(t *testing.T) {
+	w := newTestWatcher()
+
+	// Events are only kept while a timeline is recorded.
+	push(w, KeyDown, "KeyW", 50)
+	if got := len(w.StopTimeline().Events); got != 0 {
+		t.Fatalf("timeline kept %d events without being started", got)
+	}
+
+	offset := 250 * time.Millisecond
+
+	w.mu.Lock()
+	w.recording = true
+	w.timeline = Timeline{ClockOffset: offset, Initial: w.action}
+	w.mu.Unlock()
+
+	// A tap of brake shorter than a tick, while accelerating.
+	push(w, KeyDown, "KeyS", 100)
+	push(w, KeyUp, "KeyS", 130)
+	push(w, KeyUp, "KeyW", 300)
+
+	timeline := w.StopTimeline()
+	if got := len(timeline.Events); got != 3 {
+		t.Fatalf("timeline has %d events, want 3", got)
+	}
+
+	if got, want := timeline.Events[0].Time, at(100, offset); !got.Equal(want) {
+		t.Errorf("event time = %v, want %v on the clock of the process", got, want)
+	}
+
+	tests := []struct {
+		ms   float64
+		want game.Action
+	}{
+		{ms: 0, want: game.Action{Throttle: game.ThrottleAccelerate}},
+		{ms: 99.999, want: game.Action{Throttle: game.ThrottleAccelerate}},
+		{ms: 100, want: game.Action{Throttle: game.ThrottleBrake}},
+		{ms: 129, want: game.Action{Throttle: game.ThrottleBrake}},
+		{ms: 130, want: game.Action{Throttle: game.ThrottleAccelerate}},
+		{ms: 300, want: game.Action{}},
+		{ms: 1000, want: game.Action{}},
+	}
+
+	for _, tt := range tests {
+		if got := timeline.ActionAt(at(tt.ms, offset)); got != tt.want {
+			t.Errorf("ActionAt(%vms) = %+v, want %+v", tt.ms, got, tt.want)
+		}
+	}
+
+	// Events after the timeline stopped are dropped.
+	push(w, KeyDown, "KeyW", 400)
+	if got := len(w.StopTimeline().Events); got != 0 {
+		t.Errorf("timeline kept %d events after being stopped", got)
+	}
+}
+
+func TestWatcherClose(t *testing.T) {
+	w := newTestWatcher()
+
+	// A watcher without a listener closes as is.
+	w.Close()
+
+	stops := 0
+	w.stopListening = func() error {
+		stops++
+		return nil
+	}
+
+	w.Close()
+	w.Close()
+
+	if stops != 1 {
+		t.Errorf("Close() stopped listening %d times, want once", stops)
+	}
+}
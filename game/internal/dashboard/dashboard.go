@@ -0,0 +1,318 @@
+// Package dashboard serves a live view of the workers as they play:
+// their latest frame as MJPEG, along with their action, held keys, latencies and lap state.
+package dashboard
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/controller"
+	"github.com/nizarmah/stig/game/internal/driver"
+	"github.com/nizarmah/stig/game/internal/game"
+)
+
+//go:embed static
+var static embed.FS
+
+// mjpegBoundary separates the frames of the MJPEG stream.
+const mjpegBoundary = "frame"
+
+// Dashboard is the live view of the workers.
+// A nil *Dashboard is valid and shows nothing, so the dashboard can be disabled.
+type Dashboard struct {
+	mu      sync.Mutex
+	workers map[int]*Worker
+}
+
+// New creates a new dashboard.
+func New() *Dashboard {
+	return &Dashboard{
+		workers: map[int]*Worker{},
+	}
+}
+
+// Worker returns the view of a worker, following the state of its game and the keys its controller holds.
+func (d *Dashboard) Worker(
+	index int,
+	gameClient *game.Client,
+	controllerClient *controller.Client,
+) *Worker {
+	if d == nil {
+		return nil
+	}
+
+	w := &Worker{
+		controller: controllerClient,
+		index:      index,
+		state:      game.StateUnknown,
+		changed:    make(chan struct{}),
+	}
+
+	gameClient.OnTransition(func(t game.Transition) {
+		w.update(func() {
+			w.state = t.To
+		})
+	})
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.workers[index] = w
+
+	return w
+}
+
+// Serve serves the dashboard at the address until the context is done.
+func (d *Dashboard) Serve(ctx context.Context, addr string) error {
+	server := &http.Server{Addr: addr, Handler: d.handler()}
+
+	go func() {
+		<-ctx.Done()
+		if err := server.Close(); err != nil {
+			log.Println(fmt.Sprintf("failed to close dashboard server: %v", err))
+		}
+	}()
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve dashboard: %w", err)
+	}
+
+	return nil
+}
+
+// handler routes the page of the dashboard and the views of the workers.
+func (d *Dashboard) handler() http.Handler {
+	root, err := fs.Sub(static, "static")
+	if err != nil {
+		// The embedded directory is always there.
+		panic(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /{$}", http.FileServerFS(root))
+	mux.HandleFunc("GET /workers", d.handleWorkers)
+	mux.HandleFunc("GET /workers/{worker}/frame.mjpeg", d.handleFrames)
+	mux.HandleFunc("GET /workers/{worker}/state", d.handleState)
+
+	return mux
+}
+
+// handleWorkers lists the indexes of the workers.
+func (d *Dashboard) handleWorkers(w http.ResponseWriter, _ *http.Request) {
+	d.mu.Lock()
+	indexes := make([]int, 0, len(d.workers))
+	for index := range d.workers {
+		indexes = append(indexes, index)
+	}
+	d.mu.Unlock()
+
+	slices.Sort(indexes)
+	writeJSON(w, indexes)
+}
+
+// handleState writes the state of a worker as JSON.
+func (d *Dashboard) handleState(w http.ResponseWriter, r *http.Request) {
+	worker, ok := d.worker(w, r)
+	if !ok {
+		return
+	}
+
+	state, _, _ := worker.snapshot()
+	writeJSON(w, state)
+}
+
+// handleFrames streams the frames of a worker as MJPEG, as they're captured.
+func (d *Dashboard) handleFrames(w http.ResponseWriter, r *http.Request) {
+	worker, ok := d.worker(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+mjpegBoundary)
+	w.Header().Set("Cache-Control", "no-store")
+
+	flusher, _ := w.(http.Flusher)
+	for {
+		_, frame, changed := worker.snapshot()
+
+		if frame != nil {
+			if _, err := fmt.Fprintf(
+				w,
+				"--%s\r\nContent-Type: image/jpeg\r\nContent-Length: %d\r\n\r\n",
+				mjpegBoundary,
+				len(frame),
+			); err != nil {
+				return
+			}
+
+			if _, err := w.Write(frame); err != nil {
+				return
+			}
+
+			if _, err := fmt.Fprint(w, "\r\n"); err != nil {
+				return
+			}
+
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-changed:
+		}
+	}
+}
+
+// worker returns the worker of the request, or writes an error.
+func (d *Dashboard) worker(w http.ResponseWriter, r *http.Request) (*Worker, bool) {
+	index, err := strconv.Atoi(r.PathValue("worker"))
+	if err != nil {
+		http.Error(w, "invalid worker", http.StatusBadRequest)
+		return nil, false
+	}
+
+	d.mu.Lock()
+	worker, ok := d.workers[index]
+	d.mu.Unlock()
+
+	if !ok {
+		http.Error(w, "unknown worker", http.StatusNotFound)
+		return nil, false
+	}
+
+	return worker, true
+}
+
+// writeJSON writes a value as JSON.
+func writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Println(fmt.Sprintf("failed to write dashboard response: %v", err))
+	}
+}
+
+// Worker is the live view of a worker.
+// A nil *Worker is valid and records nothing.
+type Worker struct {
+	controller *controller.Client
+	index      int
+
+	// mu guards the fields below.
+	mu sync.Mutex
+	// changed is closed, and replaced, whenever the view changes.
+	changed chan struct{}
+	// lap is the index of the current lap.
+	lap int
+	// state is the state of the game.
+	state game.State
+	// tick is the last applied tick, if any.
+	tick *driver.Tick
+}
+
+// State is the state of a worker, as shown on the dashboard.
+type State struct {
+	// Action is the last action applied.
+	Action game.Action `json:"action"`
+	// Apply is the time to apply the last action.
+	Apply time.Duration `json:"apply_ns"`
+	// Capture is the time to capture the last frame.
+	Capture time.Duration `json:"capture_ns"`
+	// Held are the codes of the keys the controller holds.
+	Held []string `json:"held"`
+	// Infer is the time for the agent to act on the last frame.
+	Infer time.Duration `json:"infer_ns"`
+	// Lap is the index of the current lap.
+	Lap int `json:"lap"`
+	// Latency is the time from capturing the last frame to applying its action.
+	Latency time.Duration `json:"latency_ns"`
+	// State is the state of the game.
+	State game.State `json:"state"`
+	// Tick is the index of the last tick within the lap.
+	Tick int `json:"tick"`
+	// Time is when the last frame was captured.
+	Time time.Time `json:"time"`
+	// Worker is the index of the worker.
+	Worker int `json:"worker"`
+}
+
+// StartLap records the start of a lap.
+func (w *Worker) StartLap(lap int) {
+	if w == nil {
+		return
+	}
+
+	w.update(func() {
+		w.lap = lap
+		w.tick = nil
+	})
+}
+
+// Observe records an applied tick, and can be used as the driver's OnTick.
+func (w *Worker) Observe(tick driver.Tick) {
+	if w == nil {
+		return
+	}
+
+	w.update(func() {
+		w.tick = &tick
+	})
+}
+
+// update changes the view, and wakes up its viewers.
+func (w *Worker) update(fn func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	fn()
+
+	close(w.changed)
+	w.changed = make(chan struct{})
+}
+
+// snapshot returns the state of the worker, its last frame,
+// and a channel closed once the view changes.
+func (w *Worker) snapshot() (State, []byte, <-chan struct{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	state := State{
+		Held:   []string{},
+		Lap:    w.lap,
+		State:  w.state,
+		Worker: w.index,
+	}
+
+	for _, key := range w.controller.Held() {
+		state.Held = append(state.Held, key.Info().Code)
+	}
+
+	var frame []byte
+	if w.tick != nil {
+		state.Action = w.tick.Action
+		state.Apply = w.tick.Apply
+		state.Capture = w.tick.Capture
+		state.Infer = w.tick.Infer
+		state.Latency = w.tick.Latency
+		state.Tick = w.tick.Tick
+		state.Time = w.tick.Time
+		frame = w.tick.Frame
+	}
+
+	return state, frame, w.changed
+}
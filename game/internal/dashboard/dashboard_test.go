@@ -0,0 +1,195 @@
+package dashboard
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/controller"
+	"github.com/nizarmah/stig/game/internal/driver"
+	"github.com/nizarmah/stig/game/internal/game"
+)
+
+// newTestDashboard serves a dashboard of workers without a game, as Worker would add them.
+func newTestDashboard(t *testing.T, indexes ...int) (*Dashboard, string) {
+	t.Helper()
+
+	d := New()
+	for _, index := range indexes {
+		d.workers[index] = &Worker{
+			controller: controller.NewClient(nil),
+			index:      index,
+			state:      game.StateUnknown,
+			changed:    make(chan struct{}),
+		}
+	}
+
+	server := httptest.NewServer(d.handler())
+	t.Cleanup(server.Close)
+
+	return d, server.URL
+}
+
+// getJSON gets a JSON value from the dashboard.
+func getJSON(t *testing.T, url string, value any) {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("failed to get %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s status = %d, want %d", url, resp.StatusCode, http.StatusOK)
+	}
+
+	if got := resp.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("GET %s content type = %q, want application/json", url, got)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(value); err != nil {
+		t.Fatalf("failed to decode %s: %v", url, err)
+	}
+}
+
+func TestDashboardWorkers(t *testing.T) {
+	_, url := newTestDashboard(t, 2, 0, 1)
+
+	indexes := []int{}
+	getJSON(t, url+"/workers", &indexes)
+
+	if want := []int{0, 1, 2}; !slices.Equal(indexes, want) {
+		t.Errorf("workers = %v, want %v", indexes, want)
+	}
+}
+
+func TestDashboardState(t *testing.T) {
+	d, url := newTestDashboard(t, 0)
+	worker := d.workers[0]
+
+	state := State{}
+	getJSON(t, url+"/workers/0/state", &state)
+
+	if state.State != game.StateUnknown || state.Held == nil || state.Tick != 0 {
+		t.Errorf("state before a lap = %+v, want an unknown state without ticks", state)
+	}
+
+	captured := time.Unix(1_700_000_000, 0).UTC()
+	worker.StartLap(3)
+	worker.Observe(driver.Tick{
+		Action:  game.Action{Throttle: game.ThrottleAccelerate, Steering: game.SteeringLeft},
+		Apply:   2 * time.Millisecond,
+		Capture: 10 * time.Millisecond,
+		Infer:   30 * time.Millisecond,
+		Latency: 42 * time.Millisecond,
+		Tick:    7,
+		Time:    captured,
+	})
+
+	getJSON(t, url+"/workers/0/state", &state)
+
+	want := State{
+		Action:  game.Action{Throttle: game.ThrottleAccelerate, Steering: game.SteeringLeft},
+		Apply:   2 * time.Millisecond,
+		Capture: 10 * time.Millisecond,
+		Held:    []string{},
+		Infer:   30 * time.Millisecond,
+		Lap:     3,
+		Latency: 42 * time.Millisecond,
+		State:   game.StateUnknown,
+		Tick:    7,
+		Time:    captured,
+		Worker:  0,
+	}
+
+	if state.Action != want.Action || state.Apply != want.Apply || state.Capture != want.Capture ||
+		state.Infer != want.Infer || state.Lap != want.Lap || state.Latency != want.Latency ||
+		state.Tick != want.Tick || !state.Time.Equal(want.Time) || state.Worker != want.Worker {
+		t.Errorf("state = %+v, want %+v", state, want)
+	}
+
+	// A new lap clears the last tick.
+	worker.StartLap(4)
+	getJSON(t, url+"/workers/0/state", &state)
+
+	if state.Lap != 4 || state.Tick != 0 || state.Action != (game.Action{}) {
+		t.Errorf("state after a new lap = %+v, want lap 4 without ticks", state)
+	}
+}
+
+func TestDashboardUnknownWorker(t *testing.T) {
+	_, url := newTestDashboard(t, 0)
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{path: "/workers/1/state", want: http.StatusNotFound},
+		{path: "/workers/x/state", want: http.StatusBadRequest},
+		{path: "/workers/1/frame.mjpeg", want: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		resp, err := http.Get(url + tt.path)
+		if err != nil {
+			t.Fatalf("failed to get %s: %v", tt.path, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != tt.want {
+			t.Errorf("GET %s status = %d, want %d", tt.path, resp.StatusCode, tt.want)
+		}
+	}
+}
+
+func TestDashboardFrames(t *testing.T) {
+	d, url := newTestDashboard(t, 0)
+	d.workers[0].Observe(driver.Tick{Frame: []byte("jpeg"), Tick: 1})
+
+	resp, err := http.Get(url + "/workers/0/frame.mjpeg")
+	if err != nil {
+		t.Fatalf("failed to get frames: %v", err)
+	}
+	defer resp.Body.Close()
+
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/x-mixed-replace" {
+		t.Fatalf("content type = %q, want an MJPEG stream", resp.Header.Get("Content-Type"))
+	}
+
+	part, err := multipart.NewReader(bufio.NewReader(resp.Body), params["boundary"]).NextPart()
+	if err != nil {
+		t.Fatalf("failed to read frame: %v", err)
+	}
+
+	// The part only ends with the next frame, so the frame is read by its length.
+	frame := make([]byte, len("jpeg"))
+	if _, err := io.ReadFull(part, frame); err != nil {
+		t.Fatalf("failed to read frame: %v", err)
+	}
+
+	if string(frame) != "jpeg" || part.Header.Get("Content-Type") != "image/jpeg" || part.Header.Get("Content-Length") != "4" {
+		t.Errorf("frame = %q (%s), want the observed frame", frame, part.Header.Get("Content-Type"))
+	}
+}
+
+func TestNilDashboard(t *testing.T) {
+	var d *Dashboard
+
+	worker := d.Worker(0, nil, nil)
+	if worker != nil {
+		t.Fatalf("Worker() = %v, want nil", worker)
+	}
+
+	// A nil worker records nothing.
+	worker.StartLap(1)
+	worker.Observe(driver.Tick{Tick: 1})
+}
@@ -0,0 +1,347 @@
+// Package driver drives the car by feeding the screen to an agent and applying its actions.
+package driver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/agent"
+	"github.com/nizarmah/stig/game/internal/controller"
+	"github.com/nizarmah/stig/game/internal/game"
+	"github.com/nizarmah/stig/game/internal/metrics"
+	"github.com/nizarmah/stig/game/internal/screen"
+	"github.com/nizarmah/stig/game/internal/stats"
+)
+
+// Configuration is the configuration for the driver.
+type Configuration struct {
+	// Agent decides the actions.
+	Agent agent.Agent
+	// Controller applies the actions.
+	Controller *controller.Client
+	// Debug is whether to debug the driver.
+	Debug bool
+	// Depth is the number of frames inferred concurrently (0 runs each tick sequentially).
+	// When positive, the next frame is captured while the previous ones are being inferred.
+	Depth int
+	// Metrics records the actions that failed to apply, if set.
+	Metrics *metrics.Metrics
+	// OnTick is called with every applied tick, if set.
+	OnTick func(Tick)
+	// Screen captures the frames.
+	Screen *screen.Client
+}
+
+// Driver drives the car for a single lap.
+type Driver struct {
+	agent      agent.Agent
+	controller *controller.Client
+	debug      bool
+	depth      int
+	metrics    *metrics.Metrics
+	onTick     func(Tick)
+	screen     *screen.Client
+
+	// timings are the timings of each stage.
+	timings timings
+
+	// applyMu serializes applying actions, so a stale action never overrides a fresher one.
+	// It's apart from mu, so capturing the next frame doesn't wait on the keys being dispatched.
+	applyMu sync.Mutex
+	// applied is the tick of the last applied action, or -1.
+	applied int
+
+	// mu guards the fields below.
+	mu sync.Mutex
+	// tick is the index of the next tick.
+	tick int
+	// dropped is the number of frames dropped before being inferred.
+	dropped int
+	// err is the last error of the inference workers, until the game loop reports it.
+	err error
+}
+
+// timings collects the timings of each stage.
+type timings struct {
+	capture stats.Durations
+	infer   stats.Durations
+	apply   stats.Durations
+	latency stats.Durations
+}
+
+// Timings summarizes the timings of each stage.
+type Timings struct {
+	// Capture is the time to capture a frame.
+	Capture stats.Summary `json:"capture"`
+	// Infer is the time for the agent to act on a frame.
+	Infer stats.Summary `json:"infer"`
+	// Apply is the time to apply an action.
+	Apply stats.Summary `json:"apply"`
+	// Latency is the time from capturing a frame to applying its action.
+	Latency stats.Summary `json:"latency"`
+	// Dropped is the number of frames dropped before being inferred.
+	Dropped int `json:"dropped"`
+}
+
+// Tick is what the driver saw and did on a tick.
+type Tick struct {
+	// Action is the action applied.
+	Action game.Action
+	// Apply is the time to apply the action.
+	Apply time.Duration
+	// Capture is the time to capture the frame.
+	Capture time.Duration
+	// Frame is the captured frame.
+	Frame []byte
+	// Infer is the time for the agent to act on the frame.
+	Infer time.Duration
+	// Latency is the time from capturing the frame to applying its action.
+	Latency time.Duration
+	// Tick is the index of the tick within the lap.
+	Tick int
+	// Time is when the frame was captured.
+	Time time.Time
+}
+
+// frame is a captured frame waiting to be inferred.
+type frame struct {
+	obs agent.Observation
+	// start is when the capture started.
+	start time.Time
+	// infer is the time the agent took to act on the frame.
+	infer time.Duration
+}
+
+// New creates a new driver.
+func New(cfg Configuration) *Driver {
+	return &Driver{
+		agent:      cfg.Agent,
+		controller: cfg.Controller,
+		debug:      cfg.Debug,
+		depth:      cfg.Depth,
+		metrics:    cfg.Metrics,
+		onTick:     cfg.OnTick,
+		screen:     cfg.Screen,
+		applied:    -1,
+	}
+}
+
+// Drive drives the car in the game loop until the context is done,
+// and returns the stats of the game loop along with its last error.
+func (d *Driver) Drive(ctx context.Context, gameClient *game.Client) (game.LoopStats, error) {
+	if d.depth <= 0 {
+		return gameClient.RunInGameLoop(ctx, d.step)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Start the inference workers.
+	frames := make(chan frame, d.depth)
+
+	wg := sync.WaitGroup{}
+	for range d.depth {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.infer(ctx, frames)
+		}()
+	}
+
+	loopStats, err := gameClient.RunInGameLoop(ctx, func(ctx context.Context) error {
+		return d.enqueue(ctx, frames)
+	})
+
+	cancel()
+	wg.Wait()
+
+	return loopStats, err
+}
+
+// Timings returns the timings of each stage so far.
+func (d *Driver) Timings() Timings {
+	d.mu.Lock()
+	dropped := d.dropped
+	d.mu.Unlock()
+
+	return Timings{
+		Capture: d.timings.capture.Summary(),
+		Infer:   d.timings.infer.Summary(),
+		Apply:   d.timings.apply.Summary(),
+		Latency: d.timings.latency.Summary(),
+		Dropped: dropped,
+	}
+}
+
+// AgentLatencies returns the time the agent took to act on each frame so far.
+func (d *Driver) AgentLatencies() []time.Duration {
+	return d.timings.infer.Samples()
+}
+
+// step captures, infers and applies a frame in sequence.
+func (d *Driver) step(ctx context.Context) error {
+	f, err := d.capture(ctx)
+	if err != nil {
+		return err
+	}
+
+	action, err := d.act(ctx, &f)
+	if err != nil {
+		return err
+	}
+
+	return d.apply(f, action)
+}
+
+// enqueue captures a frame and queues it for inference,
+// dropping the oldest queued frame when the queue is full.
+// It reports the last error of the inference workers on their behalf.
+func (d *Driver) enqueue(ctx context.Context, frames chan frame) error {
+	d.mu.Lock()
+	err := d.err
+	d.err = nil
+	d.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	f, err := d.capture(ctx)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case frames <- f:
+			return nil
+
+		default:
+			select {
+			case old := <-frames:
+				d.mu.Lock()
+				d.dropped++
+				d.mu.Unlock()
+
+				if d.debug {
+					log.Println(fmt.Sprintf("driver dropped frame %d", old.obs.Tick))
+				}
+
+			default:
+			}
+		}
+	}
+}
+
+// infer acts on queued frames and applies the freshest actions.
+func (d *Driver) infer(ctx context.Context, frames chan frame) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case f := <-frames:
+			action, err := d.act(ctx, &f)
+			if err == nil {
+				err = d.apply(f, action)
+			}
+
+			if err != nil && ctx.Err() == nil {
+				d.mu.Lock()
+				d.err = err
+				d.mu.Unlock()
+			}
+		}
+	}
+}
+
+// capture captures the next frame.
+func (d *Driver) capture(ctx context.Context) (frame, error) {
+	start := time.Now()
+
+	data, err := d.screen.Peek(ctx)
+	if err != nil {
+		return frame{}, fmt.Errorf("failed to capture screen: %w", err)
+	}
+
+	captured := time.Now()
+	d.timings.capture.Add(captured.Sub(start))
+
+	d.mu.Lock()
+	tick := d.tick
+	d.tick++
+	d.mu.Unlock()
+
+	return frame{
+		obs: agent.Observation{
+			Frame: data,
+			Tick:  tick,
+			Time:  captured,
+		},
+		start: start,
+	}, nil
+}
+
+// act asks the agent for the action to take on a frame.
+func (d *Driver) act(ctx context.Context, f *frame) (game.Action, error) {
+	start := time.Now()
+
+	action, err := d.agent.Act(ctx, f.obs)
+	if err != nil {
+		return game.Action{}, fmt.Errorf("failed to predict action: %w", err)
+	}
+
+	f.infer = time.Since(start)
+	d.timings.infer.Add(f.infer)
+
+	return action, nil
+}
+
+// apply applies the action of a frame, unless a fresher frame was applied already.
+// The tick is reported once the action is applied, outside of the lock.
+func (d *Driver) apply(f frame, action game.Action) error {
+	d.applyMu.Lock()
+
+	if f.obs.Tick <= d.applied {
+		d.applyMu.Unlock()
+
+		if d.debug {
+			log.Println(fmt.Sprintf("driver skipped stale action for frame %d", f.obs.Tick))
+		}
+
+		return nil
+	}
+
+	start := time.Now()
+	if err := d.controller.Apply(action); err != nil {
+		d.applyMu.Unlock()
+
+		d.metrics.ApplyError()
+		return fmt.Errorf("failed to apply action: %w", err)
+	}
+
+	applied := time.Now()
+	d.applied = f.obs.Tick
+	d.applyMu.Unlock()
+
+	d.timings.apply.Add(applied.Sub(start))
+	d.timings.latency.Add(applied.Sub(f.start))
+
+	if d.onTick != nil {
+		d.onTick(Tick{
+			Action:  action,
+			Apply:   applied.Sub(start),
+			Capture: f.obs.Time.Sub(f.start),
+			Frame:   f.obs.Frame,
+			Infer:   f.infer,
+			Latency: applied.Sub(f.start),
+			Tick:    f.obs.Tick,
+			Time:    f.obs.Time,
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/game"
+)
+
+// Lap resets the game, drives until the lap finishes, and returns its result.
+// The agent errors are left to the caller, since only it knows how they're counted.
+func (d *Driver) Lap(
+	parentCtx context.Context,
+	gameClient *game.Client,
+	timeout time.Duration,
+) (game.LapResult, error) {
+	result := game.LapResult{}
+
+	// Lap context.
+	ctx, cancel := context.WithTimeout(parentCtx, timeout)
+	defer cancel()
+
+	// Reset the game, and wait for the race to start.
+	if err := gameClient.ResetGame(ctx); err != nil {
+		err = fmt.Errorf("failed to reset game: %w", err)
+		result.Fail(err)
+		return result, err
+	}
+
+	driveCtx, stopDriving := context.WithCancel(ctx)
+	defer stopDriving()
+
+	var loopStats game.LoopStats
+	var loopErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		loopStats, loopErr = d.Drive(driveCtx, gameClient)
+	}()
+
+	// Wait for the game to finish.
+	finishErr := gameClient.WaitForFinish(ctx)
+
+	// Stop driving before collecting the stats.
+	stopDriving()
+	<-done
+
+	result.Ticks = loopStats.Ticks
+	result.DroppedTicks = loopStats.Missed + d.Timings().Dropped
+	result.LoopErrors = loopStats.Errors
+
+	if finishErr != nil {
+		err := fmt.Errorf("failed to wait for game to finish: %w", finishErr)
+		if loopErr != nil {
+			err = fmt.Errorf("%w (last game loop error: %v)", err, loopErr)
+		}
+
+		result.Fail(err)
+		return result, err
+	}
+
+	// Read the lap time.
+	lapTime, err := gameClient.GetLapTime(ctx)
+	if err != nil {
+		err = fmt.Errorf("failed to get lap time: %w", err)
+		result.Fail(err)
+		return result, err
+	}
+
+	result.LapTime = lapTime
+	result.Status = game.LapFinished
+
+	return result, nil
+}
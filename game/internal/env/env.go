@@ -1,49 +1,488 @@
-// Package env provides environment variables for the application.
+// Package env loads the configuration of the application
+// from defaults, a config file, environment variables and command-line flags.
 package env
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"log"
+	"net/url"
 	"os"
+	"reflect"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 )
 
-// Lookup looks up a string from the environment variable.
-func Lookup(key string) (string, error) {
-	value, ok := os.LookupEnv(key)
-	if !ok {
-		return "", fmt.Errorf("env var %q not set", key)
+const (
+	// configFileEnv is the environment variable of the config file.
+	configFileEnv = "CONFIG_FILE"
+	// configFileFlag is the command-line flag of the config file.
+	configFileFlag = "config-file"
+)
+
+// Validator is a configuration that checks itself once loaded.
+type Validator interface {
+	Validate() error
+}
+
+// Setting is a field of a configuration, loaded from a config file, the environment and the command line.
+//
+// Fields are described with struct tags:
+//   - env is the environment variable, also used to name the flag (GAME_URL is --game-url).
+//   - default is the value used when none is set.
+//   - help is the description of the setting.
+//   - unit is the unit of a time.Duration given as a number ("ms", "s"), seconds by default.
+//   - min and max bound a number, a duration (in its unit), or the number of items of a list.
+//   - enum lists the allowed values, comma-separated.
+//   - url lists the allowed schemes of a URL, comma-separated. An empty URL is allowed unless required.
+//   - required is whether the setting can't be empty.
+//   - replaces is the former environment variable of the setting, still read with a deprecation warning.
+//   - replacedunit is the unit of the former environment variable of a time.Duration, seconds by default.
+//
+// Embedded structs are flattened, so settings can be shared across configurations.
+type Setting struct {
+	// Default is the default value of the setting.
+	Default string
+	// Enum are the allowed values of the setting, if limited.
+	Enum []string
+	// Env is the environment variable of the setting.
+	Env string
+	// Flag is the command-line flag of the setting.
+	Flag string
+	// Help is the description of the setting.
+	Help string
+	// Max is the highest value of the setting, if bounded.
+	Max string
+	// Min is the lowest value of the setting, if bounded.
+	Min string
+	// Replaces is the former environment variable of the setting, if it was renamed.
+	Replaces string
+	// Required is whether the setting can't be empty.
+	Required bool
+	// Schemes are the allowed schemes of the setting, if it's a URL.
+	Schemes []string
+
+	field        reflect.Value
+	replacedUnit time.Duration
+	unit         time.Duration
+}
+
+// Settings returns the settings of a configuration, a pointer to a struct.
+func Settings(cfg any) ([]*Setting, error) {
+	value := reflect.ValueOf(cfg)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("configuration must be a pointer to a struct, got %T", cfg)
 	}
 
-	return value, nil
+	return settings(value.Elem())
 }
 
-// LookupBool looks up a boolean from the environment variable.
-func LookupBool(key string) (bool, error) {
-	value, err := Lookup(key)
+// settings returns the settings of the fields of a struct.
+func settings(value reflect.Value) ([]*Setting, error) {
+	all := []*Setting{}
+
+	for i := range value.NumField() {
+		field := value.Type().Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			embedded, err := settings(value.Field(i))
+			if err != nil {
+				return nil, err
+			}
+
+			all = append(all, embedded...)
+			continue
+		}
+
+		key, ok := field.Tag.Lookup("env")
+		if !ok {
+			continue
+		}
+
+		unit, err := parseUnit(field.Tag.Get("unit"))
+		if err != nil {
+			return nil, fmt.Errorf("setting %s: %w", key, err)
+		}
+
+		replacedUnit, err := parseUnit(field.Tag.Get("replacedunit"))
+		if err != nil {
+			return nil, fmt.Errorf("setting %s: %w", key, err)
+		}
+
+		all = append(all, &Setting{
+			Default:      field.Tag.Get("default"),
+			Enum:         splitList(field.Tag.Get("enum")),
+			Env:          key,
+			Flag:         strings.ReplaceAll(strings.ToLower(key), "_", "-"),
+			Help:         field.Tag.Get("help"),
+			Max:          field.Tag.Get("max"),
+			Min:          field.Tag.Get("min"),
+			Replaces:     field.Tag.Get("replaces"),
+			Required:     field.Tag.Get("required") == "true",
+			Schemes:      splitList(field.Tag.Get("url")),
+			field:        value.Field(i),
+			replacedUnit: replacedUnit,
+			unit:         unit,
+		})
+	}
+
+	return all, nil
+}
+
+// Values returns the values of the settings of a configuration, by environment variable.
+func Values(cfg any) (map[string]string, error) {
+	all, err := Settings(cfg)
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+
+	values := make(map[string]string, len(all))
+	for _, s := range all {
+		values[s.Env] = s.String()
 	}
 
-	return strconv.ParseBool(value)
+	return values, nil
 }
 
-// LookupInt looks up an integer from the environment variable.
-func LookupInt(key string) (int, error) {
-	value, err := Lookup(key)
+// Load fills a configuration, a pointer to a struct, from the defaults of its settings,
+// then the config file, then the environment, then the command-line arguments.
+//
+// The config file is a YAML map of environment variables to values,
+// set with the CONFIG_FILE environment variable or the --config-file flag.
+//
+// It returns flag.ErrHelp when the arguments ask for help,
+// and otherwise an error listing every invalid setting.
+func Load(cfg any, name string, args []string, output io.Writer) error {
+	all, err := Settings(cfg)
 	if err != nil {
-		return 0, err
+		return err
+	}
+
+	// Parse the flags, to apply them last.
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(output)
+
+	configFile := flags.String(
+		configFileFlag,
+		os.Getenv(configFileEnv),
+		fmt.Sprintf("YAML file of settings, under the environment (env %s)", configFileEnv),
+	)
+
+	flagValues := map[string]string{}
+	for _, s := range all {
+		flags.Var(&flagValue{setting: s, values: flagValues}, s.Flag, s.usage())
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", flags.Args())
+	}
+
+	fileValues := map[string]string{}
+	if *configFile != "" {
+		if fileValues, err = readConfigFile(*configFile); err != nil {
+			return err
+		}
+	}
+
+	// Apply the layers, and check the values.
+	errs := []error{}
+	for _, s := range all {
+		value, source, unit := s.Default, "default", s.unit
+
+		// Read the former name of the setting under the current one, in its own unit.
+		if v, ok := fileValues[s.Replaces]; ok && s.Replaces != "" {
+			value, source, unit = v, "config file", s.replacedUnit
+			log.Println(fmt.Sprintf("%s in config file %s is deprecated, use %s (%s)", s.Replaces, *configFile, s.Env, s.Help))
+		}
+
+		if v, ok := fileValues[s.Env]; ok {
+			value, source, unit = v, "config file", s.unit
+		}
+
+		if v, ok := os.LookupEnv(s.Replaces); ok && s.Replaces != "" {
+			value, source, unit = v, "env", s.replacedUnit
+			log.Println(fmt.Sprintf("%s is deprecated, use %s (%s)", s.Replaces, s.Env, s.Help))
+		}
+
+		if v, ok := os.LookupEnv(s.Env); ok {
+			value, source, unit = v, "env", s.unit
+		}
+
+		if v, ok := flagValues[s.Env]; ok {
+			value, source, unit = v, "flag", s.unit
+		}
+
+		if err := s.set(value, unit); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid value %q from %s: %w", s.Env, value, source, err))
+			continue
+		}
+
+		if err := s.check(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.Env, err))
+		}
+	}
+
+	for key := range fileValues {
+		if !slices.ContainsFunc(all, func(s *Setting) bool { return s.Env == key || s.Replaces == key }) {
+			errs = append(errs, fmt.Errorf("%s: unknown setting in config file %s", key, *configFile))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	if validator, ok := cfg.(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Set parses a value into the setting.
+func (s *Setting) Set(value string) error {
+	return s.set(value, s.unit)
+}
+
+// set parses a value into the setting, with durations given as a number of units.
+func (s *Setting) set(value string, unit time.Duration) error {
+	switch s.field.Interface().(type) {
+	case string:
+		s.field.SetString(value)
+
+	case bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+
+		s.field.SetBool(b)
+
+	case int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+
+		s.field.SetInt(int64(n))
+
+	case uint64:
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		s.field.SetUint(n)
+
+	case time.Duration:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+
+		s.field.SetInt(int64(time.Duration(n) * unit))
+
+	case []string:
+		s.field.Set(reflect.ValueOf(splitList(value)))
+
+	default:
+		return fmt.Errorf("unsupported setting type %s", s.field.Type())
 	}
 
-	return strconv.Atoi(value)
+	return nil
 }
 
-// LookupDuration looks up a duration from the environment variable.
-func LookupDuration(key string, unitTime time.Duration) (time.Duration, error) {
-	value, err := LookupInt(key)
+// String formats the value of the setting, as it would be set.
+func (s *Setting) String() string {
+	switch value := s.field.Interface().(type) {
+	case time.Duration:
+		return strconv.FormatInt(int64(value/s.unit), 10)
+
+	case []string:
+		return strings.Join(value, ",")
+
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// check checks the value of the setting against its rules.
+func (s *Setting) check() error {
+	values := []string{s.String()}
+	if items, ok := s.field.Interface().([]string); ok {
+		values = items
+	}
+
+	if s.Required && s.String() == "" {
+		return errors.New("must be set")
+	}
+
+	if n, ok := s.number(); ok {
+		if s.Min != "" {
+			low, err := strconv.ParseFloat(s.Min, 64)
+			if err != nil {
+				return fmt.Errorf("invalid min %q: %w", s.Min, err)
+			}
+
+			if n < low {
+				return fmt.Errorf("%s is below the min of %s", s.quantity(), s.Min)
+			}
+		}
+
+		if s.Max != "" {
+			high, err := strconv.ParseFloat(s.Max, 64)
+			if err != nil {
+				return fmt.Errorf("invalid max %q: %w", s.Max, err)
+			}
+
+			if n > high {
+				return fmt.Errorf("%s is above the max of %s", s.quantity(), s.Max)
+			}
+		}
+	}
+
+	for _, value := range values {
+		if len(s.Enum) > 0 && !slices.Contains(s.Enum, value) {
+			return fmt.Errorf("%q is not one of %s", value, strings.Join(s.Enum, ", "))
+		}
+
+		if len(s.Schemes) > 0 && value != "" {
+			if err := checkURL(value, s.Schemes); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// number returns the value of the setting as a number to bound, in its unit.
+func (s *Setting) number() (float64, bool) {
+	switch value := s.field.Interface().(type) {
+	case int:
+		return float64(value), true
+
+	case uint64:
+		return float64(value), true
+
+	case time.Duration:
+		return float64(value) / float64(s.unit), true
+
+	case []string:
+		return float64(len(value)), true
+	}
+
+	return 0, false
+}
+
+// quantity describes the bounded value of the setting.
+func (s *Setting) quantity() string {
+	if items, ok := s.field.Interface().([]string); ok {
+		return fmt.Sprintf("%d items", len(items))
+	}
+
+	return s.String()
+}
+
+// usage describes the setting for the help of its flag.
+func (s *Setting) usage() string {
+	usage := s.Help
+	if len(s.Enum) > 0 {
+		usage += fmt.Sprintf(", one of %s", strings.Join(s.Enum, ", "))
+	}
+
+	items := ""
+	if _, ok := s.field.Interface().([]string); ok {
+		items = " items"
+	}
+
+	switch {
+	case s.Min != "" && s.Max != "":
+		usage += fmt.Sprintf(", from %s to %s%s", s.Min, s.Max, items)
+
+	case s.Min != "":
+		usage += fmt.Sprintf(", at least %s%s", s.Min, items)
+
+	case s.Max != "":
+		usage += fmt.Sprintf(", at most %s%s", s.Max, items)
+	}
+
+	return fmt.Sprintf("%s (env %s)", usage, s.Env)
+}
+
+// flagValue is a flag of a setting, recording the value it's set to.
+// The value is applied after the config file and the environment.
+type flagValue struct {
+	setting *Setting
+	values  map[string]string
+}
+
+// Set records the value of the flag.
+func (f *flagValue) Set(value string) error {
+	f.values[f.setting.Env] = value
+	return nil
+}
+
+// String returns the default value of the flag.
+func (f *flagValue) String() string {
+	// The flag package formats a zero flagValue to detect default values.
+	if f.setting == nil {
+		return ""
+	}
+
+	return f.setting.Default
+}
+
+// IsBoolFlag lets boolean settings be set with a bare flag.
+func (f *flagValue) IsBoolFlag() bool {
+	return f.setting != nil && f.setting.field.Kind() == reflect.Bool
+}
+
+// checkURL checks that a value is an absolute URL with one of the schemes.
+func checkURL(value string, schemes []string) error {
+	u, err := url.Parse(value)
 	if err != nil {
-		return 0, err
+		return fmt.Errorf("invalid URL %q: %w", value, err)
+	}
+
+	if !slices.Contains(schemes, u.Scheme) || u.Host == "" {
+		return fmt.Errorf("%q is not a URL with a scheme of %s", value, strings.Join(schemes, ", "))
+	}
+
+	return nil
+}
+
+// parseUnit parses the unit of a duration setting.
+func parseUnit(unit string) (time.Duration, error) {
+	switch unit {
+	case "", "s":
+		return time.Second, nil
+
+	case "ms":
+		return time.Millisecond, nil
+	}
+
+	return 0, fmt.Errorf("unknown unit %q", unit)
+}
+
+// splitList splits a comma-separated list, dropping empty items.
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
 
-	return time.Duration(value) * unitTime, nil
+	return items
 }
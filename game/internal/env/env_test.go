@@ -0,0 +1,578 @@
+package env
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testShared is embedded in the test configuration, like the settings shared by the commands.
+type testShared struct {
+	Name string `env:"TEST_NAME" default:"stig" required:"true" help:"name"`
+}
+
+// testConfig is a configuration with a setting of each type and rule.
+type testConfig struct {
+	testShared
+
+	Count   int           `env:"TEST_COUNT" default:"3" min:"1" max:"5" help:"count"`
+	Debug   bool          `env:"TEST_DEBUG" default:"false" help:"debug"`
+	Mode    string        `env:"TEST_MODE" default:"vote" enum:"vote,first" help:"mode"`
+	Seed    uint64        `env:"TEST_SEED" default:"0" help:"seed"`
+	Timeout time.Duration `env:"TEST_TIMEOUT_MS" default:"200" unit:"ms" min:"1" replaces:"TEST_TIMEOUT" replacedunit:"s" help:"timeout, in milliseconds"`
+	URL     string        `env:"TEST_URL" url:"http,https" help:"url"`
+	URLs    []string      `env:"TEST_URLS" default:"http://a,ws://b" min:"2" url:"http,ws" help:"urls"`
+	Wait    time.Duration `env:"TEST_WAIT" default:"2" help:"wait"`
+}
+
+// validatedConfig is a configuration that checks itself once loaded.
+type validatedConfig struct {
+	Low  int `env:"TEST_LOW" default:"1"`
+	High int `env:"TEST_HIGH" default:"2"`
+}
+
+// Validate checks that the bounds are in order.
+func (c *validatedConfig) Validate() error {
+	if c.Low > c.High {
+		return errors.New("TEST_LOW must not be above TEST_HIGH")
+	}
+
+	return nil
+}
+
+// clearEnv unsets the environment variables of the test configurations for the test.
+func clearEnv(t *testing.T) {
+	t.Helper()
+
+	for _, key := range []string{
+		configFileEnv,
+		"TEST_COUNT",
+		"TEST_DEBUG",
+		"TEST_HIGH",
+		"TEST_LOW",
+		"TEST_MODE",
+		"TEST_NAME",
+		"TEST_SEED",
+		"TEST_TIMEOUT",
+		"TEST_TIMEOUT_MS",
+		"TEST_URL",
+		"TEST_URLS",
+		"TEST_WAIT",
+	} {
+		// Setenv restores the variable once the test is done.
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+}
+
+// writeConfigFile writes a config file for the test, and returns its path.
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	clearEnv(t)
+
+	cfg := &testConfig{}
+	if err := Load(cfg, "test", nil, io.Discard); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := &testConfig{
+		testShared: testShared{Name: "stig"},
+		Count:      3,
+		Mode:       "vote",
+		Timeout:    200 * time.Millisecond,
+		URLs:       []string{"http://a", "ws://b"},
+		Wait:       2 * time.Second,
+	}
+
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Load() = %+v, want %+v", cfg, want)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  string
+		args []string
+		want int
+	}{
+		{
+			name: "default",
+			want: 3,
+		},
+		{
+			name: "config file over default",
+			file: "TEST_COUNT: 4",
+			want: 4,
+		},
+		{
+			name: "env over config file",
+			file: "TEST_COUNT: 4",
+			env:  "2",
+			want: 2,
+		},
+		{
+			name: "flag over env",
+			file: "TEST_COUNT: 4",
+			env:  "2",
+			args: []string{"--test-count", "5"},
+			want: 5,
+		},
+		{
+			name: "flag over default",
+			args: []string{"--test-count=1"},
+			want: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+
+			if tt.file != "" {
+				t.Setenv(configFileEnv, writeConfigFile(t, tt.file))
+			}
+
+			if tt.env != "" {
+				t.Setenv("TEST_COUNT", tt.env)
+			}
+
+			cfg := &testConfig{}
+			if err := Load(cfg, "test", tt.args, io.Discard); err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if cfg.Count != tt.want {
+				t.Errorf("Count = %d, want %d", cfg.Count, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFileFlag(t *testing.T) {
+	clearEnv(t)
+	t.Setenv(configFileEnv, writeConfigFile(t, "TEST_MODE: vote"))
+
+	path := writeConfigFile(t, strings.Join([]string{
+		"TEST_MODE: first",
+		"TEST_URLS:",
+		"  - http://a",
+		"  - http://b",
+		"  - ws://c",
+		"TEST_URL:",
+	}, "\n"))
+
+	cfg := &testConfig{}
+	if err := Load(cfg, "test", []string{"--config-file", path}, io.Discard); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.Mode != "first" {
+		t.Errorf("Mode = %q, want %q from the flag's config file", cfg.Mode, "first")
+	}
+
+	if want := []string{"http://a", "http://b", "ws://c"}; !reflect.DeepEqual(cfg.URLs, want) {
+		t.Errorf("URLs = %v, want %v", cfg.URLs, want)
+	}
+
+	if cfg.URL != "" {
+		t.Errorf("URL = %q, want empty", cfg.URL)
+	}
+}
+
+func TestLoadValues(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		check func(cfg *testConfig) bool
+	}{
+		{
+			name:  "bare bool flag",
+			args:  []string{"--test-debug"},
+			check: func(cfg *testConfig) bool { return cfg.Debug },
+		},
+		{
+			name:  "duration in milliseconds",
+			args:  []string{"--test-timeout-ms", "1500"},
+			check: func(cfg *testConfig) bool { return cfg.Timeout == 1500*time.Millisecond },
+		},
+		{
+			name:  "duration in seconds by default",
+			args:  []string{"--test-wait", "90"},
+			check: func(cfg *testConfig) bool { return cfg.Wait == 90*time.Second },
+		},
+		{
+			name:  "list with spaces and empty items",
+			args:  []string{"--test-urls", " http://a, ,ws://b ,"},
+			check: func(cfg *testConfig) bool { return reflect.DeepEqual(cfg.URLs, []string{"http://a", "ws://b"}) },
+		},
+		{
+			name:  "unsigned number",
+			args:  []string{"--test-seed", "18446744073709551615"},
+			check: func(cfg *testConfig) bool { return cfg.Seed == 18446744073709551615 },
+		},
+		{
+			name:  "embedded setting",
+			args:  []string{"--test-name", "hammond"},
+			check: func(cfg *testConfig) bool { return cfg.Name == "hammond" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+
+			cfg := &testConfig{}
+			if err := Load(cfg, "test", tt.args, io.Discard); err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if !tt.check(cfg) {
+				t.Errorf("Load(%v) = %+v", tt.args, cfg)
+			}
+		})
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "below the min",
+			args: []string{"--test-count", "0"},
+			want: "TEST_COUNT: 0 is below the min of 1",
+		},
+		{
+			name: "above the max",
+			args: []string{"--test-count", "6"},
+			want: "TEST_COUNT: 6 is above the max of 5",
+		},
+		{
+			name: "duration below the min, in its unit",
+			args: []string{"--test-timeout-ms", "0"},
+			want: "TEST_TIMEOUT_MS: 0 is below the min of 1",
+		},
+		{
+			name: "list below the min",
+			args: []string{"--test-urls", "http://a"},
+			want: "TEST_URLS: 1 items is below the min of 2",
+		},
+		{
+			name: "not in the enum",
+			args: []string{"--test-mode", "priority"},
+			want: `TEST_MODE: "priority" is not one of vote, first`,
+		},
+		{
+			name: "URL with another scheme",
+			args: []string{"--test-url", "ws://localhost:8080"},
+			want: `TEST_URL: "ws://localhost:8080" is not a URL with a scheme of http, https`,
+		},
+		{
+			name: "URL without a host",
+			args: []string{"--test-url", "localhost:8080"},
+			want: `TEST_URL: "localhost:8080" is not a URL with a scheme of http, https`,
+		},
+		{
+			name: "list item with another scheme",
+			args: []string{"--test-urls", "http://a,https://b"},
+			want: `TEST_URLS: "https://b" is not a URL with a scheme of http, ws`,
+		},
+		{
+			name: "required and empty",
+			args: []string{"--test-name", ""},
+			want: "TEST_NAME: must be set",
+		},
+		{
+			name: "not a number",
+			args: []string{"--test-count", "three"},
+			want: `TEST_COUNT: invalid value "three" from flag: strconv.Atoi: parsing "three": invalid syntax`,
+		},
+		{
+			name: "not a bool",
+			args: []string{"--test-debug=maybe"},
+			want: `TEST_DEBUG: invalid value "maybe" from flag: strconv.ParseBool: parsing "maybe": invalid syntax`,
+		},
+		{
+			name: "unexpected argument",
+			args: []string{"extra"},
+			want: "unexpected arguments: [extra]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+
+			err := Load(&testConfig{}, "test", tt.args, io.Discard)
+			if err == nil {
+				t.Fatalf("Load(%v) error = nil, want %q", tt.args, tt.want)
+			}
+
+			if err.Error() != tt.want {
+				t.Errorf("Load(%v) error = %q, want %q", tt.args, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadAggregatesErrors(t *testing.T) {
+	clearEnv(t)
+	t.Setenv(configFileEnv, writeConfigFile(t, "TEST_MODE: priority"))
+	t.Setenv("TEST_URL", "ftp://localhost")
+
+	err := Load(&testConfig{}, "test", []string{"--test-count", "9"}, io.Discard)
+	if err == nil {
+		t.Fatal("Load() error = nil, want every invalid setting")
+	}
+
+	want := strings.Join([]string{
+		"TEST_COUNT: 9 is above the max of 5",
+		`TEST_MODE: "priority" is not one of vote, first`,
+		`TEST_URL: "ftp://localhost" is not a URL with a scheme of http, https`,
+	}, "\n")
+
+	if err.Error() != want {
+		t.Errorf("Load() error =\n%s\nwant\n%s", err, want)
+	}
+}
+
+func TestLoadSourceInError(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  string
+		want string
+	}{
+		{
+			name: "config file",
+			file: "TEST_SEED: -1",
+			want: `TEST_SEED: invalid value "-1" from config file`,
+		},
+		{
+			name: "env",
+			env:  "-1",
+			want: `TEST_SEED: invalid value "-1" from env`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+
+			if tt.file != "" {
+				t.Setenv(configFileEnv, writeConfigFile(t, tt.file))
+			}
+
+			if tt.env != "" {
+				t.Setenv("TEST_SEED", tt.env)
+			}
+
+			err := Load(&testConfig{}, "test", nil, io.Discard)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want prefix %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadUnknownConfigFileKey(t *testing.T) {
+	clearEnv(t)
+
+	path := writeConfigFile(t, "TEST_COUNT: 4\nTEST_COLOR: red")
+	t.Setenv(configFileEnv, path)
+
+	err := Load(&testConfig{}, "test", nil, io.Discard)
+	if err == nil {
+		t.Fatal("Load() error = nil, want unknown setting")
+	}
+
+	if want := "TEST_COLOR: unknown setting in config file " + path; err.Error() != want {
+		t.Errorf("Load() error = %q, want %q", err, want)
+	}
+}
+
+func TestLoadReplacedSetting(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		file string
+		want time.Duration
+	}{
+		{
+			name: "env",
+			env:  map[string]string{"TEST_TIMEOUT": "2"},
+			want: 2 * time.Second,
+		},
+		{
+			name: "config file",
+			file: "TEST_TIMEOUT: 3",
+			want: 3 * time.Second,
+		},
+		{
+			name: "current name first",
+			env:  map[string]string{"TEST_TIMEOUT": "2", "TEST_TIMEOUT_MS": "500"},
+			want: 500 * time.Millisecond,
+		},
+		{
+			name: "env over config file",
+			env:  map[string]string{"TEST_TIMEOUT": "2"},
+			file: "TEST_TIMEOUT_MS: 500",
+			want: 2 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			if tt.file != "" {
+				t.Setenv(configFileEnv, writeConfigFile(t, tt.file))
+			}
+
+			cfg := testConfig{}
+			if err := Load(&cfg, "test", nil, io.Discard); err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if cfg.Timeout != tt.want {
+				t.Errorf("Timeout = %v, want %v", cfg.Timeout, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "nested map",
+			content: "TEST_MODE:\n  value: vote",
+			want:    "TEST_MODE must be a value or a list",
+		},
+		{
+			name:    "not a map",
+			content: "- TEST_MODE",
+			want:    "failed to parse config file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv(configFileEnv, writeConfigFile(t, tt.content))
+
+			err := Load(&testConfig{}, "test", nil, io.Discard)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		clearEnv(t)
+		t.Setenv(configFileEnv, filepath.Join(t.TempDir(), "missing.yaml"))
+
+		err := Load(&testConfig{}, "test", nil, io.Discard)
+		if err == nil || !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("Load() error = %v, want %v", err, os.ErrNotExist)
+		}
+	})
+}
+
+func TestLoadHelp(t *testing.T) {
+	clearEnv(t)
+
+	output := &strings.Builder{}
+	err := Load(&testConfig{}, "test", []string{"--help"}, output)
+	if !errors.Is(err, flag.ErrHelp) {
+		t.Fatalf("Load(--help) error = %v, want %v", err, flag.ErrHelp)
+	}
+
+	for _, want := range []string{
+		"-test-count",
+		"count, from 1 to 5 (env TEST_COUNT)",
+		"mode, one of vote, first (env TEST_MODE)",
+		"urls, at least 2 items (env TEST_URLS)",
+		"-config-file",
+	} {
+		if !strings.Contains(output.String(), want) {
+			t.Errorf("help is missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestLoadValidator(t *testing.T) {
+	clearEnv(t)
+
+	if err := Load(&validatedConfig{}, "test", nil, io.Discard); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	err := Load(&validatedConfig{}, "test", []string{"--test-low", "3"}, io.Discard)
+	if err == nil || err.Error() != "TEST_LOW must not be above TEST_HIGH" {
+		t.Errorf("Load() error = %v, want the validation error", err)
+	}
+}
+
+func TestLoadRejectsNonStruct(t *testing.T) {
+	if err := Load(testConfig{}, "test", nil, io.Discard); err == nil {
+		t.Error("Load() of a struct value error = nil, want an error")
+	}
+}
+
+func TestValues(t *testing.T) {
+	clearEnv(t)
+
+	cfg := &testConfig{}
+	if err := Load(cfg, "test", []string{"--test-timeout-ms", "1500", "--test-wait", "90"}, io.Discard); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	values, err := Values(cfg)
+	if err != nil {
+		t.Fatalf("Values() error = %v", err)
+	}
+
+	want := map[string]string{
+		"TEST_COUNT":      "3",
+		"TEST_DEBUG":      "false",
+		"TEST_MODE":       "vote",
+		"TEST_NAME":       "stig",
+		"TEST_SEED":       "0",
+		"TEST_TIMEOUT_MS": "1500",
+		"TEST_URL":        "",
+		"TEST_URLS":       "http://a,ws://b",
+		"TEST_WAIT":       "90",
+	}
+
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("Values() = %v, want %v", values, want)
+	}
+}
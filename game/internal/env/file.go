@@ -0,0 +1,47 @@
+package env
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// readConfigFile reads the values of a config file, a YAML map of environment variables to values.
+// Lists are joined with commas, like they're set in the environment.
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	raw := map[string]any{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		switch value := value.(type) {
+		case nil:
+			values[key] = ""
+
+		case []any:
+			items := make([]string, 0, len(value))
+			for _, item := range value {
+				items = append(items, fmt.Sprint(item))
+			}
+
+			values[key] = strings.Join(items, ",")
+
+		case map[string]any:
+			return nil, fmt.Errorf("config file %s: %s must be a value or a list", path, key)
+
+		default:
+			values[key] = fmt.Sprint(value)
+		}
+	}
+
+	return values, nil
+}
@@ -0,0 +1,34 @@
+package eval
+
+import (
+	"context"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/agent"
+	"github.com/nizarmah/stig/game/internal/driver"
+	"github.com/nizarmah/stig/game/internal/game"
+)
+
+// PlayLap plays a lap with a new driver, and returns it along with the error that ended it, if any.
+// The fallback is the agent the driver acts through, and counts its errors.
+func PlayLap(
+	ctx context.Context,
+	gameClient *game.Client,
+	driverConfig driver.Configuration,
+	fallback *agent.Fallback,
+	timeout time.Duration,
+) (Lap, error) {
+	fallback.Reset()
+	driverClient := driver.New(driverConfig)
+
+	result, err := driverClient.Lap(ctx, gameClient, timeout)
+
+	fallbacks := fallback.Stats()
+	result.AgentErrors = fallbacks.Total()
+
+	return Lap{
+		AgentLatencies: driverClient.AgentLatencies(),
+		AgentTimeouts:  fallbacks.Timeouts,
+		Result:         result,
+	}, err
+}
@@ -0,0 +1,197 @@
+// Package eval scores an agent from the laps it played.
+package eval
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/agent"
+	"github.com/nizarmah/stig/game/internal/game"
+	"github.com/nizarmah/stig/game/internal/stats"
+)
+
+// Lap is a lap played by the agent.
+type Lap struct {
+	// AgentLatencies are the times the agent took to act on each frame.
+	AgentLatencies []time.Duration
+	// AgentTimeouts is the number of frames the agent failed to act on in time.
+	AgentTimeouts int
+	// Result is the result of the lap.
+	Result game.LapResult
+}
+
+// Thresholds are the thresholds a report must meet to pass.
+// Zero thresholds are disabled.
+type Thresholds struct {
+	// MaxAgentP90Latency is the highest p90 agent latency.
+	MaxAgentP90Latency time.Duration
+	// MaxMeanLapTime is the highest mean lap time.
+	MaxMeanLapTime time.Duration
+	// MaxP90LapTime is the highest p90 lap time.
+	MaxP90LapTime time.Duration
+	// MinCompletionRate is the lowest percentage of finished laps.
+	MinCompletionRate float64
+}
+
+// Report is the evaluation of an agent.
+type Report struct {
+	// Agent is the description of the agent.
+	Agent agent.Info `json:"agent"`
+	// AgentErrors is the number of frames the agent failed to act on.
+	AgentErrors int `json:"agent_errors"`
+	// AgentLatency summarizes the time the agent took to act on each frame.
+	AgentLatency stats.Summary `json:"agent_latency"`
+	// AgentTimeouts is the number of frames the agent failed to act on in time.
+	AgentTimeouts int `json:"agent_timeouts"`
+	// CompletionRate is the percentage of finished laps.
+	CompletionRate float64 `json:"completion_rate"`
+	// Error is the error that stopped the evaluation before it could play its laps, if any.
+	// The report then covers no laps, and fails.
+	Error string `json:"error,omitempty"`
+	// Failures are the thresholds the report didn't meet.
+	Failures []string `json:"failures"`
+	// Finished is the number of finished laps.
+	Finished int `json:"finished"`
+	// Interrupted is whether the evaluation was stopped before all its laps were played.
+	// The report then covers the laps played so far, and fails.
+	Interrupted bool `json:"interrupted"`
+	// LapTime summarizes the time of the finished laps.
+	LapTime stats.Summary `json:"lap_time"`
+	// LapTimeouts is the number of laps that ran out of time.
+	LapTimeouts int `json:"lap_timeouts"`
+	// Laps is the number of laps played.
+	Laps int `json:"laps"`
+	// Passed is whether the report met all the thresholds.
+	Passed bool `json:"passed"`
+	// Results are the results of each lap.
+	Results []game.LapResult `json:"results"`
+}
+
+// NewReport creates a new report from the laps played by an agent.
+func NewReport(info agent.Info, laps []Lap) Report {
+	report := Report{
+		Agent:    info,
+		Failures: []string{},
+		Laps:     len(laps),
+		Passed:   true,
+		Results:  make([]game.LapResult, 0, len(laps)),
+	}
+
+	lapTimes := []time.Duration{}
+	latencies := []time.Duration{}
+	for _, lap := range laps {
+		report.AgentErrors += lap.Result.AgentErrors
+		report.AgentTimeouts += lap.AgentTimeouts
+		report.Results = append(report.Results, lap.Result)
+		latencies = append(latencies, lap.AgentLatencies...)
+
+		switch lap.Result.Status {
+		case game.LapFinished:
+			report.Finished++
+			lapTimes = append(lapTimes, lap.Result.LapTime)
+
+		case game.LapTimedOut:
+			report.LapTimeouts++
+		}
+	}
+
+	if report.Laps > 0 {
+		report.CompletionRate = 100 * float64(report.Finished) / float64(report.Laps)
+	}
+
+	report.AgentLatency = stats.Summarize(latencies)
+	report.LapTime = stats.Summarize(lapTimes)
+
+	return report
+}
+
+// Check checks the report against the thresholds, and records the ones it didn't meet.
+func (r *Report) Check(thresholds Thresholds) bool {
+	fail := func(format string, args ...any) {
+		r.Failures = append(r.Failures, fmt.Sprintf(format, args...))
+	}
+
+	if r.Error != "" {
+		fail("evaluation failed: %s", r.Error)
+	}
+
+	if r.Interrupted {
+		fail("evaluation was interrupted after %d laps", r.Laps)
+	}
+
+	if thresholds.MinCompletionRate > 0 && r.CompletionRate < thresholds.MinCompletionRate {
+		fail("completion rate %.1f%% is below %.1f%%", r.CompletionRate, thresholds.MinCompletionRate)
+	}
+
+	if thresholds.MaxMeanLapTime > 0 && (r.Finished == 0 || r.LapTime.Mean > thresholds.MaxMeanLapTime) {
+		fail("mean lap time %s is above %s", r.LapTime.Mean, thresholds.MaxMeanLapTime)
+	}
+
+	if thresholds.MaxP90LapTime > 0 && (r.Finished == 0 || r.LapTime.P90 > thresholds.MaxP90LapTime) {
+		fail("p90 lap time %s is above %s", r.LapTime.P90, thresholds.MaxP90LapTime)
+	}
+
+	if thresholds.MaxAgentP90Latency > 0 && r.AgentLatency.P90 > thresholds.MaxAgentP90Latency {
+		fail("p90 agent latency %s is above %s", r.AgentLatency.P90, thresholds.MaxAgentP90Latency)
+	}
+
+	r.Passed = len(r.Failures) == 0
+
+	return r.Passed
+}
+
+// WriteTable writes the report as a readable table.
+func (r Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "lap\tstatus\tlap time\tticks\tdropped\tagent errors\n")
+	for _, result := range r.Results {
+		fmt.Fprintf(
+			tw,
+			"%d\t%s\t%s\t%d\t%d\t%d\n",
+			result.Lap,
+			result.Status,
+			formatLapTime(result),
+			result.Ticks,
+			result.DroppedTicks,
+			result.AgentErrors,
+		)
+	}
+
+	fmt.Fprintf(tw, "\n")
+	fmt.Fprintf(tw, "agent\t%s\n", r.Agent)
+	fmt.Fprintf(tw, "completion\t%d/%d laps (%.1f%%)\n", r.Finished, r.Laps, r.CompletionRate)
+	fmt.Fprintf(
+		tw,
+		"lap time\tbest %s, mean %s, median %s, p90 %s\n",
+		r.LapTime.Min,
+		r.LapTime.Mean.Round(time.Millisecond),
+		r.LapTime.P50,
+		r.LapTime.P90,
+	)
+	fmt.Fprintf(tw, "timeouts\t%d laps, %d agent frames\n", r.LapTimeouts, r.AgentTimeouts)
+	fmt.Fprintf(tw, "agent errors\t%d\n", r.AgentErrors)
+	fmt.Fprintf(tw, "agent latency\t%s\n", r.AgentLatency)
+
+	if r.Passed {
+		fmt.Fprintf(tw, "result\tpassed\n")
+	} else {
+		fmt.Fprintf(tw, "result\tfailed\n")
+		for _, failure := range r.Failures {
+			fmt.Fprintf(tw, "\t%s\n", failure)
+		}
+	}
+
+	return tw.Flush()
+}
+
+// formatLapTime formats the lap time of a result, if it finished.
+func formatLapTime(result game.LapResult) string {
+	if result.Status != game.LapFinished {
+		return "-"
+	}
+
+	return result.LapTime.String()
+}
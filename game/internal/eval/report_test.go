@@ -0,0 +1,57 @@
+package eval
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/agent"
+	"github.com/nizarmah/stig/game/internal/game"
+)
+
+func TestCheckInterrupted(t *testing.T) {
+	laps := []Lap{
+		{Result: game.LapResult{LapTime: time.Minute, Status: game.LapFinished}},
+		{Result: game.LapResult{LapTime: time.Minute, Status: game.LapFinished}},
+	}
+
+	report := NewReport(agent.Info{}, laps)
+	if !report.Check(Thresholds{MinCompletionRate: 100}) {
+		t.Fatalf("Check() failed a complete report: %v", report.Failures)
+	}
+
+	// The same laps fail once the evaluation was stopped before playing them all.
+	report = NewReport(agent.Info{}, laps)
+	report.Interrupted = true
+
+	if report.Check(Thresholds{MinCompletionRate: 100}) {
+		t.Fatal("Check() passed an interrupted report")
+	}
+
+	want := []string{"evaluation was interrupted after 2 laps"}
+	if strings.Join(report.Failures, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Failures = %q, want %q", report.Failures, want)
+	}
+
+	if report.Laps != 2 || report.Finished != 2 {
+		t.Errorf("report covers %d/%d laps, want the 2 played", report.Finished, report.Laps)
+	}
+}
+
+func TestCheckError(t *testing.T) {
+	report := NewReport(agent.Info{}, nil)
+	report.Error = "failed to handshake with agent: agent is down"
+
+	if report.Check(Thresholds{}) {
+		t.Fatal("Check() passed a report of an evaluation that failed")
+	}
+
+	want := []string{"evaluation failed: failed to handshake with agent: agent is down"}
+	if strings.Join(report.Failures, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Failures = %q, want %q", report.Failures, want)
+	}
+
+	if report.Laps != 0 || len(report.Results) != 0 {
+		t.Errorf("report covers %d laps, want none", report.Laps)
+	}
+}
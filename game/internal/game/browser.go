@@ -0,0 +1,181 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
+)
+
+// Browser is a browser that can hold several game clients.
+type Browser struct {
+	// browser is the browser instance.
+	browser *rod.Browser
+	// launcher is the launcher of the browser, if we launched it.
+	launcher *launcher.Launcher
+}
+
+// OpenBrowser launches a browser, or attaches to the configured one.
+func OpenBrowser(ctx context.Context, config ClientConfig) (*Browser, error) {
+	// Launch a browser, unless told to attach to one.
+	controlURL := config.BrowserWSURL
+
+	var browserLauncher *launcher.Launcher
+	if controlURL == "" {
+		var err error
+		browserLauncher, controlURL, err = launchBrowser(
+			ctx,
+			config.BrowserBin,
+			config.BrowserHeadless,
+			config.WindowHeight,
+			config.WindowWidth,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to launch browser: %w", err)
+		}
+
+		if config.Debug {
+			log.Println(fmt.Sprintf("launched browser: %s", controlURL))
+		}
+	}
+
+	browser := rod.New().
+		Context(ctx).
+		ControlURL(controlURL)
+	if err := browser.Connect(); err != nil {
+		closeLauncher(browserLauncher)
+		return nil, fmt.Errorf("failed to connect to browser: %w", err)
+	}
+
+	return &Browser{
+		browser:  browser,
+		launcher: browserLauncher,
+	}, nil
+}
+
+// NewClient creates a new game client on a new page of the browser.
+func (b *Browser) NewClient(
+	ctx context.Context,
+	config ClientConfig,
+	timeout time.Duration,
+) (*Client, error) {
+	page, err := openGamePage(
+		ctx,
+		b.browser,
+		config.GameURL,
+		config.NewWindow,
+		config.WindowHeight,
+		config.WindowWidth,
+		timeout,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open game page: %w", err)
+	}
+
+	client := &Client{
+		browser: b,
+		debug:   config.Debug,
+		fps:     config.FPS,
+		metrics: config.Metrics,
+		Page:    page,
+		states:  newStateMachine(),
+	}
+
+	// Follow the state of the game as the page reports it.
+	if err := client.watchState(ctx); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to watch game state: %w", err)
+	}
+
+	return client, nil
+}
+
+// Close closes the browser.
+// Only browsers we launched are closed,
+// since closing an attached browser causes friction while testing.
+func (b *Browser) Close() {
+	if b.launcher == nil {
+		return
+	}
+
+	if err := b.browser.Close(); err != nil {
+		log.Println(fmt.Sprintf("failed to close browser: %v", err))
+	}
+
+	closeLauncher(b.launcher)
+}
+
+// launchBrowser launches a local browser with a temporary profile,
+// and returns its launcher and control URL.
+func launchBrowser(
+	ctx context.Context,
+	bin string,
+	headless bool,
+	windowHeight int,
+	windowWidth int,
+) (*launcher.Launcher, string, error) {
+	profileDir, err := os.MkdirTemp("", "stig-profile-")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create profile directory: %w", err)
+	}
+
+	browserLauncher := launcher.New().
+		Context(ctx).
+		Headless(headless).
+		UserDataDir(profileDir).
+		Set("window-size", fmt.Sprintf("%d,%d", windowWidth, windowHeight))
+
+	// Prefer the given browser, then a local one, then let rod download one.
+	if bin == "" {
+		bin, _ = launcher.LookPath()
+	}
+
+	if bin != "" {
+		browserLauncher = browserLauncher.Bin(bin)
+	}
+
+	controlURL, err := browserLauncher.Launch()
+	if err != nil {
+		os.RemoveAll(profileDir)
+		return nil, "", err
+	}
+
+	return browserLauncher, controlURL, nil
+}
+
+// closeLauncher kills the launched browser and removes its profile.
+func closeLauncher(browserLauncher *launcher.Launcher) {
+	if browserLauncher == nil {
+		return
+	}
+
+	browserLauncher.Kill()
+	browserLauncher.Cleanup()
+}
+
+// OpenBrowsers opens several browsers, closing them all if any fails.
+func OpenBrowsers(ctx context.Context, config ClientConfig, n int) ([]*Browser, error) {
+	browsers := make([]*Browser, 0, n)
+	for range n {
+		browser, err := OpenBrowser(ctx, config)
+		if err != nil {
+			CloseBrowsers(browsers)
+			return nil, err
+		}
+
+		browsers = append(browsers, browser)
+	}
+
+	return browsers, nil
+}
+
+// CloseBrowsers closes several browsers.
+func CloseBrowsers(browsers []*Browser) {
+	for _, browser := range browsers {
+		browser.Close()
+	}
+}
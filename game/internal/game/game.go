@@ -9,11 +9,16 @@ import (
 
 	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/proto"
+	"github.com/nizarmah/stig/game/internal/metrics"
 )
 
 // ClientConfig is the configuration for the browser client.
 type ClientConfig struct {
-	// BrowserWSURL is the websocket URL of the browser.
+	// BrowserBin is the path of the browser to launch (empty looks up a local Chrome or Chromium).
+	BrowserBin string
+	// BrowserHeadless is whether to launch the browser without a window.
+	BrowserHeadless bool
+	// BrowserWSURL is the websocket URL of the browser to attach to (empty launches one).
 	BrowserWSURL string
 	// Debug is whether to print debug information.
 	Debug bool
@@ -21,6 +26,10 @@ type ClientConfig struct {
 	FPS int
 	// GameURL is the URL of the game.
 	GameURL string
+	// Metrics records the ticks of the game loop, if set.
+	Metrics *metrics.Metrics
+	// NewWindow is whether to open the game in a new window, so pages sharing a browser all render.
+	NewWindow bool
 	// WindowHeight is the height of the window.
 	WindowHeight int
 	// WindowWidth is the width of the window.
@@ -29,59 +38,63 @@ type ClientConfig struct {
 
 // Client is a client for the browser.
 type Client struct {
-	// browser is the browser instance.
-	browser *rod.Browser
+	// browser is the browser of the page.
+	browser *Browser
 	// debug is whether to print debug information.
 	debug bool
 	// fps is the frames per second of the game loop.
 	fps int
+	// metrics records the ticks of the game loop, if set.
+	metrics *metrics.Metrics
+	// ownsBrowser is whether closing the client closes the browser.
+	ownsBrowser bool
 	// Page is the Page of the game.
 	Page *rod.Page
+	// states tracks the state of the game.
+	states *stateMachine
+	// stopWatchingState stops the page from reporting its state.
+	stopWatchingState func() error
 }
 
-// NewClient creates a new game client.
+// NewClient creates a new game client in its own browser.
 func NewClient(
 	ctx context.Context,
 	config ClientConfig,
 	timeout time.Duration,
 ) (*Client, error) {
-	browser := rod.New().
-		Context(ctx).
-		ControlURL(config.BrowserWSURL)
-	if err := browser.Connect(); err != nil {
-		return nil, fmt.Errorf("failed to connect to browser: %w", err)
+	browser, err := OpenBrowser(ctx, config)
+	if err != nil {
+		return nil, err
 	}
 
-	page, err := openGamePage(
-		ctx,
-		browser,
-		config.GameURL,
-		config.WindowHeight,
-		config.WindowWidth,
-		timeout,
-	)
+	client, err := browser.NewClient(ctx, config, timeout)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open game page: %w", err)
+		browser.Close()
+		return nil, err
 	}
 
-	return &Client{
-		browser: browser,
-		debug:   config.Debug,
-		fps:     config.FPS,
-		Page:    page,
-	}, nil
+	client.ownsBrowser = true
+
+	return client, nil
 }
 
 // Close closes the game client.
 func (c *Client) Close() {
+	c.states.stop()
+
+	if c.stopWatchingState != nil {
+		if err := c.stopWatchingState(); err != nil {
+			log.Println(fmt.Sprintf("failed to stop watching state: %v", err))
+		}
+	}
+
 	if err := c.Page.Close(); err != nil {
 		log.Println(fmt.Sprintf("failed to close page: %v", err))
 	}
 
-	// Commenting because this causes friction while testing.
-	// if err := c.browser.Close(); err != nil {
-	// 	log.Println(fmt.Sprintf("failed to close browser: %v", err))
-	// }
+	if c.ownsBrowser {
+		c.browser.Close()
+	}
 }
 
 // openGamePage opens the game page.
@@ -89,6 +102,7 @@ func openGamePage(
 	ctx context.Context,
 	browser *rod.Browser,
 	gameURL string,
+	newWindow bool,
 	windowHeight int,
 	windowWidth int,
 	timeout time.Duration,
@@ -96,7 +110,7 @@ func openGamePage(
 	// Open the game page.
 	page, err := browser.
 		Context(ctx).
-		Page(proto.TargetCreateTarget{URL: gameURL})
+		Page(proto.TargetCreateTarget{URL: gameURL, NewWindow: newWindow})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create page: %w", err)
 	}
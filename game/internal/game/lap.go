@@ -0,0 +1,128 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LapStatus represents how a lap ended.
+type LapStatus = string
+
+const (
+	// LapFinished is the status of a lap that crossed the finish line.
+	LapFinished LapStatus = "finished"
+	// LapTimedOut is the status of a lap that ran out of time.
+	LapTimedOut LapStatus = "timed_out"
+	// LapCanceled is the status of a lap stopped before it ended, e.g. on shutdown.
+	LapCanceled LapStatus = "canceled"
+	// LapFailed is the status of a lap that couldn't be played.
+	LapFailed LapStatus = "failed"
+)
+
+// LapResult is the result of a single lap.
+type LapResult struct {
+	// AgentErrors is the number of ticks the agent failed to act on.
+	AgentErrors int `json:"agent_errors"`
+	// DroppedTicks is the number of ticks skipped or dropped before being acted on.
+	DroppedTicks int `json:"dropped_ticks"`
+	// Error is the error that ended the lap, if any.
+	Error string `json:"error,omitempty"`
+	// Lap is the index of the lap.
+	Lap int `json:"lap"`
+	// LapTime is the lap time shown by the game, if finished.
+	LapTime time.Duration `json:"lap_time_ns"`
+	// LoopErrors is the number of ticks of the game loop that failed.
+	LoopErrors int `json:"loop_errors"`
+	// Status is how the lap ended.
+	Status LapStatus `json:"status"`
+	// Ticks is the number of ticks of the game loop.
+	Ticks int `json:"ticks"`
+	// Worker is the index of the worker that ran the lap.
+	Worker int `json:"worker"`
+}
+
+// Fail marks the lap as ended by an error, telling timeouts and cancellations apart.
+func (r *LapResult) Fail(err error) {
+	r.Error = err.Error()
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		r.Status = LapTimedOut
+
+	case errors.Is(err, context.Canceled):
+		r.Status = LapCanceled
+
+	default:
+		r.Status = LapFailed
+	}
+}
+
+// ParseLapTime parses a lap time shown by the game, as "MM:SS:mmm" (e.g., "01:49:214").
+func ParseLapTime(value string) (time.Duration, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid lap time %q: expected MM:SS:mmm", value)
+	}
+
+	units := []time.Duration{time.Minute, time.Second, time.Millisecond}
+	// limits bound the seconds and milliseconds, the minutes aren't.
+	limits := []int{0, 60, 1000}
+
+	var lapTime time.Duration
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || strings.HasPrefix(part, "+") {
+			return 0, fmt.Errorf("invalid lap time %q: expected MM:SS:mmm", value)
+		}
+
+		if limits[i] > 0 && n >= limits[i] {
+			return 0, fmt.Errorf("invalid lap time %q: %d is out of range", value, n)
+		}
+
+		lapTime += time.Duration(n) * units[i]
+	}
+
+	return lapTime, nil
+}
+
+// GetLapTime retrieves the final game time shown on the Replay screen, parsed.
+func (c *Client) GetLapTime(ctx context.Context) (time.Duration, error) {
+	value, err := c.GetReplayTime(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return ParseLapTime(value)
+}
+
+// LapResultWriter writes lap results as JSON lines, safely across workers.
+type LapResultWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewLapResultWriter creates a new lap result writer.
+func NewLapResultWriter(w io.Writer) *LapResultWriter {
+	return &LapResultWriter{
+		enc: json.NewEncoder(w),
+	}
+}
+
+// Write writes a lap result as a JSON line.
+func (w *LapResultWriter) Write(result LapResult) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.enc.Encode(result); err != nil {
+		return fmt.Errorf("failed to write lap result: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestParseLapTime(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "01:49:214", want: time.Minute + 49*time.Second + 214*time.Millisecond},
+		{value: "00:00:000", want: 0},
+		{value: "12:05:007", want: 12*time.Minute + 5*time.Second + 7*time.Millisecond},
+		{value: "100:59:999", want: 100*time.Minute + 59*time.Second + 999*time.Millisecond},
+		{value: "", wantErr: true},
+		{value: "01:49", wantErr: true},
+		{value: "01:49:214:000", wantErr: true},
+		{value: "01:49.214", wantErr: true},
+		{value: "aa:49:214", wantErr: true},
+		{value: "01::214", wantErr: true},
+		{value: "-01:49:214", wantErr: true},
+		{value: "+01:49:214", wantErr: true},
+		{value: " 01:49:214", wantErr: true},
+		{value: "01:60:000", wantErr: true},
+		{value: "01:49:1000", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseLapTime(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseLapTime(%q) = %v, want an error", tt.value, got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseLapTime(%q) error = %v", tt.value, err)
+			}
+
+			if got != tt.want {
+				t.Errorf("ParseLapTime(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLapResultFail(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want LapStatus
+	}{
+		{name: "timeout", err: context.DeadlineExceeded, want: LapTimedOut},
+		{name: "wrapped timeout", err: fmt.Errorf("failed to wait for lap: %w", context.DeadlineExceeded), want: LapTimedOut},
+		{name: "canceled", err: context.Canceled, want: LapCanceled},
+		{name: "wrapped canceled", err: fmt.Errorf("failed to press keys: %w", context.Canceled), want: LapCanceled},
+		{name: "other", err: errors.New("page crashed"), want: LapFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := LapResult{Lap: 2, Status: LapFinished}
+			result.Fail(tt.err)
+
+			if result.Status != tt.want {
+				t.Errorf("Status = %q, want %q", result.Status, tt.want)
+			}
+
+			if result.Error != tt.err.Error() {
+				t.Errorf("Error = %q, want %q", result.Error, tt.err.Error())
+			}
+		})
+	}
+}
@@ -7,11 +7,22 @@ import (
 	"time"
 )
 
-// RunInGameLoop runs a function in the game loop.
+// LoopStats counts the ticks of a game loop.
+type LoopStats struct {
+	// Ticks is the number of ticks run.
+	Ticks int
+	// Missed is the number of ticks skipped because the previous ones ran late.
+	Missed int
+	// Errors is the number of ticks that failed.
+	Errors int
+}
+
+// RunInGameLoop runs a function in the game loop until the context is done.
+// A failing tick doesn't stop the loop; the last error is returned with the stats.
 func (c *Client) RunInGameLoop(
 	ctx context.Context,
 	fn func(ctx context.Context) error,
-) error {
+) (LoopStats, error) {
 	// Create interval in milliseconds based on the fps.
 	interval := time.Second / time.Duration(c.fps)
 	if c.debug {
@@ -21,14 +32,35 @@ func (c *Client) RunInGameLoop(
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	start := time.Now()
+	stats := LoopStats{}
+
+	var lastErr error
 	for {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			// The ticker drops the ticks it can't deliver on time.
+			stats.Missed = max(0, int(time.Since(start)/interval)-stats.Ticks)
+			return stats, lastErr
 
 		case <-ticker.C:
-			if err := fn(ctx); err != nil {
-				return err
+			stats.Ticks++
+			tickStart := time.Now()
+
+			err := fn(ctx)
+
+			c.metrics.Tick(
+				float64(stats.Ticks)/time.Since(start).Seconds(),
+				time.Since(tickStart) > interval,
+			)
+
+			if err != nil && ctx.Err() == nil {
+				stats.Errors++
+				lastErr = err
+
+				if c.debug {
+					log.Println(fmt.Sprintf("game loop tick %d failed: %v", stats.Ticks, err))
+				}
 			}
 		}
 	}
@@ -36,16 +68,6 @@ func (c *Client) RunInGameLoop(
 
 // WaitForFinish waits for the game to finish and signals when it does.
 func (c *Client) WaitForFinish(ctx context.Context) error {
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-
-		default:
-			// Tie the DOM query to the same context so it respects timeouts.
-			if el, _ := c.Page.Context(ctx).Element(`div[data-your-time="true"]`); el != nil {
-				return nil
-			}
-		}
-	}
+	_, err := c.WaitFor(ctx, StateFinished)
+	return err
 }
@@ -8,8 +8,13 @@ import (
 	"github.com/go-rod/rod/lib/proto"
 )
 
-// StartGame starts the game by clicking the "Start" button.
+// StartGame starts the game from the menu by clicking the "Start" button,
+// and returns once the game leaves the menu.
 func (c *Client) StartGame(ctx context.Context) error {
+	if _, err := c.WaitFor(ctx, StateMenu); err != nil {
+		return fmt.Errorf("failed to wait for menu: %w", err)
+	}
+
 	// Search for the "Start" button.
 	startButton, err := c.Page.Context(ctx).ElementX(`//span[text()="Start"]`)
 	if err != nil {
@@ -21,15 +26,39 @@ func (c *Client) StartGame(ctx context.Context) error {
 		return fmt.Errorf("failed to click on start button: %w", err)
 	}
 
+	if _, err := c.WaitFor(ctx, StateCountdown, StateRacing); err != nil {
+		return fmt.Errorf("failed to wait for game to start: %w", err)
+	}
+
 	return nil
 }
 
-// ResetGame resets the game before the current one ends.
+// ResetGame goes back to the menu from wherever the game is,
+// starts a new game, and returns once the race starts.
 func (c *Client) ResetGame(ctx context.Context) error {
-	// Press "Escape" to go back to the main menu.
-	pressEscape := c.Page.Context(ctx).KeyActions().Type(input.Escape)
-	if err := pressEscape.Do(); err != nil {
-		return fmt.Errorf("failed to press escape: %w", err)
+	state, err := c.State(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to reset game: %w", err)
+	}
+
+	switch state {
+	case StateMenu:
+		// Already in the menu.
+
+	case StateUnknown:
+		// Let the page settle before leaving it.
+		if _, err := c.WaitFor(ctx, StateMenu, StateCountdown, StateRacing, StateFinished, StatePaused); err != nil {
+			return fmt.Errorf("failed to wait for page: %w", err)
+		}
+
+		return c.ResetGame(ctx)
+
+	default:
+		// Press "Escape" to go back to the main menu.
+		pressEscape := c.Page.Context(ctx).KeyActions().Type(input.Escape)
+		if err := pressEscape.Do(); err != nil {
+			return fmt.Errorf("failed to press escape: %w", err)
+		}
 	}
 
 	// Start the game.
@@ -37,6 +66,11 @@ func (c *Client) ResetGame(ctx context.Context) error {
 		return fmt.Errorf("failed to start game: %w", err)
 	}
 
+	// Wait for the countdown to finish.
+	if _, err := c.WaitFor(ctx, StateRacing); err != nil {
+		return fmt.Errorf("failed to wait for race: %w", err)
+	}
+
 	return nil
 }
 
@@ -0,0 +1,330 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/ysmood/gson"
+)
+
+// State represents where the game is in its lifecycle.
+type State = string
+
+const (
+	// StateUnknown is the state when the page can't tell, e.g. while it loads.
+	StateUnknown State = "unknown"
+	// StateMenu is the state when the main menu is shown.
+	StateMenu State = "menu"
+	// StateCountdown is the state between starting the game and the race.
+	StateCountdown State = "countdown"
+	// StateRacing is the state while the car can be driven.
+	StateRacing State = "racing"
+	// StateFinished is the state when the lap time is shown.
+	StateFinished State = "finished"
+	// StatePaused is the state when the game is paused, e.g. its tab is hidden.
+	StatePaused State = "paused"
+)
+
+const (
+	// countdownDuration is how long the countdown of the live game lasts after leaving the menu.
+	// The live game shows nothing in the DOM during its countdown, so it's timed instead of detected.
+	countdownDuration = 3 * time.Second
+	// stateBinding is the function the page calls when its state changes.
+	stateBinding = "__stigState"
+)
+
+// stateJS detects the state of the game from the DOM.
+//
+// The live game pauses when its tab is hidden, shows a "Start" span in its menu,
+// and shows its lap time in a div[data-your-time="true"], all of which are detected.
+// It shows nothing during its countdown though, so racing is reported right after the menu,
+// and the state machine times the countdown instead.
+// Only the mock game shows its countdown, in a [data-countdown] element.
+const stateJS = `() => {
+	const visible = (el) => !!el && el.getClientRects().length > 0
+
+	if (document.readyState !== 'complete') return 'unknown'
+	if (document.visibilityState === 'hidden') return 'paused'
+	if (document.querySelector('div[data-your-time="true"]')) return 'finished'
+
+	const start = document.evaluate(
+		'//span[text()="Start"]', document, null, XPathResult.FIRST_ORDERED_NODE_TYPE, null,
+	).singleNodeValue
+	if (visible(start)) return 'menu'
+
+	if (visible(document.querySelector('[data-countdown]'))) return 'countdown'
+
+	return 'racing'
+}`
+
+// stateObserverJS reports the state of the game to Go whenever the DOM changes.
+// It only watches the attributes that show or hide the lifecycle elements,
+// since the game may touch other attributes on every frame,
+// and detects the state at most once per animation frame, however many mutations it batches.
+// A hidden tab runs no animation frames, so visibility changes are reported right away.
+const stateObserverJS = `() => {
+	if (window.__stigStateObserver) return
+
+	const detect = %s
+	let last = null
+	const report = () => {
+		const state = detect()
+		if (state === last) return
+
+		last = state
+		window.%s(state)
+	}
+
+	let scheduled = false
+	const schedule = () => {
+		if (scheduled) return
+
+		scheduled = true
+		requestAnimationFrame(() => {
+			scheduled = false
+			report()
+		})
+	}
+
+	window.__stigStateObserver = new MutationObserver(schedule)
+	window.__stigStateObserver.observe(document, {
+		attributeFilter: ['aria-label', 'class', 'data-countdown', 'data-your-time', 'hidden', 'style'],
+		characterData: true,
+		childList: true,
+		subtree: true,
+	})
+	document.addEventListener('readystatechange', report)
+	document.addEventListener('visibilitychange', report)
+	report()
+}`
+
+// Transition is a change of the state of the game.
+type Transition struct {
+	// From is the state before the transition.
+	From State
+	// To is the state after the transition.
+	To State
+	// Time is when the transition was detected.
+	Time time.Time
+	// Timed is whether the state was timed from leaving the menu, rather than detected on the page,
+	// such as the countdown of the live game.
+	Timed bool
+}
+
+// stateMachine tracks the state of the game across detections.
+type stateMachine struct {
+	// mu guards the fields below.
+	mu sync.Mutex
+	// state is the state of the game.
+	state State
+	// detected is the last state detected on the page.
+	detected State
+	// changed is closed, and replaced, whenever the state changes.
+	changed chan struct{}
+	// countdown ends the countdown when the page doesn't show it.
+	countdown *time.Timer
+	// leftMenuAt is when the game last left the menu.
+	leftMenuAt time.Time
+	// sawCountdown is whether the page showed its countdown since leaving the menu.
+	sawCountdown bool
+	// handlers are called on every transition.
+	handlers []func(Transition)
+}
+
+// newStateMachine creates a new state machine in the unknown state.
+func newStateMachine() *stateMachine {
+	return &stateMachine{
+		state:    StateUnknown,
+		detected: StateUnknown,
+		changed:  make(chan struct{}),
+	}
+}
+
+// update moves the state machine to the detected state of the page,
+// and returns the state of the game along with the transition, if any.
+// When the page shows no countdown, as with the live game, the countdown is timed
+// from leaving the menu, and recheck is called once it should be over.
+func (m *stateMachine) update(detected State, now time.Time, recheck func()) (State, *Transition) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.detected = detected
+
+	if m.state == StateMenu && detected != StateMenu {
+		m.leftMenuAt = now
+		m.sawCountdown = false
+	}
+
+	if detected == StateCountdown {
+		m.sawCountdown = true
+	}
+
+	// Without a countdown on the page, the race starts a countdown after leaving the menu.
+	timed := false
+	if detected == StateRacing &&
+		!m.sawCountdown &&
+		!m.leftMenuAt.IsZero() &&
+		now.Sub(m.leftMenuAt) < countdownDuration {
+		detected = StateCountdown
+		timed = true
+
+		if m.countdown == nil {
+			m.countdown = time.AfterFunc(countdownDuration-now.Sub(m.leftMenuAt), recheck)
+		}
+	}
+
+	if detected != StateCountdown && m.countdown != nil {
+		m.countdown.Stop()
+		m.countdown = nil
+	}
+
+	if detected == m.state {
+		return detected, nil
+	}
+
+	transition := &Transition{
+		From:  m.state,
+		To:    detected,
+		Time:  now,
+		Timed: timed,
+	}
+	m.state = detected
+
+	close(m.changed)
+	m.changed = make(chan struct{})
+
+	return detected, transition
+}
+
+// current returns the state of the game, and a channel closed once it changes.
+func (m *stateMachine) current() (State, <-chan struct{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.state, m.changed
+}
+
+// lastDetected returns the last state detected on the page.
+func (m *stateMachine) lastDetected() State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.detected
+}
+
+// stop stops the countdown timer, if any.
+func (m *stateMachine) stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.countdown != nil {
+		m.countdown.Stop()
+		m.countdown = nil
+	}
+}
+
+// transitionHandlers returns the handlers to call on a transition.
+func (m *stateMachine) transitionHandlers() []func(Transition) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return slices.Clone(m.handlers)
+}
+
+// OnTransition registers a function called on every transition of the game.
+func (c *Client) OnTransition(fn func(Transition)) {
+	c.states.mu.Lock()
+	defer c.states.mu.Unlock()
+
+	c.states.handlers = append(c.states.handlers, fn)
+}
+
+// watchState makes the page report its state whenever the DOM changes.
+func (c *Client) watchState(ctx context.Context) error {
+	stop, err := c.Page.Expose(stateBinding, func(state gson.JSON) (interface{}, error) {
+		c.observe(state.Str())
+		return nil, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to expose state binding: %w", err)
+	}
+
+	c.stopWatchingState = stop
+
+	observer := fmt.Sprintf(stateObserverJS, stateJS, stateBinding)
+
+	// Keep watching after the page reloads.
+	if _, err := c.Page.EvalOnNewDocument(fmt.Sprintf("(%s)()", observer)); err != nil {
+		return fmt.Errorf("failed to add state observer on new documents: %w", err)
+	}
+
+	if _, err := c.Page.Context(ctx).Evaluate(&rod.EvalOptions{JS: observer}); err != nil {
+		return fmt.Errorf("failed to add state observer: %w", err)
+	}
+
+	return nil
+}
+
+// observe moves the game to the state detected on the page.
+func (c *Client) observe(detected State) {
+	recheck := func() {
+		c.observe(c.states.lastDetected())
+	}
+
+	_, transition := c.states.update(detected, time.Now(), recheck)
+	if transition == nil {
+		return
+	}
+
+	if c.debug {
+		if transition.Timed {
+			log.Println(fmt.Sprintf("game state: %s -> %s (timed, the page shows none)", transition.From, transition.To))
+		} else {
+			log.Println(fmt.Sprintf("game state: %s -> %s", transition.From, transition.To))
+		}
+	}
+
+	for _, fn := range c.states.transitionHandlers() {
+		fn(*transition)
+	}
+}
+
+// State detects the state of the game on the page.
+func (c *Client) State(ctx context.Context) (State, error) {
+	res, err := c.Page.Context(ctx).Evaluate(&rod.EvalOptions{
+		JS:      stateJS,
+		ByValue: true,
+	})
+	if err != nil {
+		state, _ := c.states.current()
+		return state, fmt.Errorf("failed to detect state: %w", err)
+	}
+
+	c.observe(res.Value.Str())
+
+	state, _ := c.states.current()
+	return state, nil
+}
+
+// WaitFor waits until the game is in one of the given states, and returns it.
+// It waits on the states the page reports, without querying it.
+func (c *Client) WaitFor(ctx context.Context, states ...State) (State, error) {
+	for {
+		state, changed := c.states.current()
+		if slices.Contains(states, state) {
+			return state, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return state, fmt.Errorf("failed to wait for %v, game is %s: %w", states, state, ctx.Err())
+
+		case <-changed:
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStateMachineUpdate(t *testing.T) {
+	// step is a state detected on the page, some time after the start of the test.
+	type step struct {
+		detected State
+		after    time.Duration
+		want     State
+		// transition is whether the step changes the state.
+		transition bool
+		// timed is whether the state is timed rather than detected.
+		timed bool
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "countdown shown on the page",
+			steps: []step{
+				{detected: StateMenu, want: StateMenu, transition: true},
+				{detected: StateCountdown, after: time.Second, want: StateCountdown, transition: true},
+				{detected: StateCountdown, after: 2 * time.Second, want: StateCountdown},
+				{detected: StateRacing, after: 4 * time.Second, want: StateRacing, transition: true},
+				{detected: StateFinished, after: time.Minute, want: StateFinished, transition: true},
+				{detected: StateMenu, after: 2 * time.Minute, want: StateMenu, transition: true},
+			},
+		},
+		{
+			name: "countdown timed without one on the page",
+			steps: []step{
+				{detected: StateMenu, want: StateMenu, transition: true},
+				{detected: StateRacing, after: time.Second, want: StateCountdown, transition: true, timed: true},
+				{detected: StateRacing, after: 3 * time.Second, want: StateCountdown},
+				{detected: StateRacing, after: 4 * time.Second, want: StateRacing, transition: true},
+				{detected: StateFinished, after: time.Minute, want: StateFinished, transition: true},
+			},
+		},
+		{
+			name: "racing detected first is not timed",
+			steps: []step{
+				{detected: StateRacing, want: StateRacing, transition: true},
+			},
+		},
+		{
+			name: "paused while racing",
+			steps: []step{
+				{detected: StateMenu, want: StateMenu, transition: true},
+				{detected: StateCountdown, after: time.Second, want: StateCountdown, transition: true},
+				{detected: StateRacing, after: 4 * time.Second, want: StateRacing, transition: true},
+				{detected: StatePaused, after: 10 * time.Second, want: StatePaused, transition: true},
+				{detected: StateRacing, after: 20 * time.Second, want: StateRacing, transition: true},
+			},
+		},
+		{
+			name: "paused during a timed countdown",
+			steps: []step{
+				{detected: StateMenu, want: StateMenu, transition: true},
+				{detected: StateRacing, after: time.Second, want: StateCountdown, transition: true, timed: true},
+				{detected: StatePaused, after: 2 * time.Second, want: StatePaused, transition: true},
+				{detected: StateRacing, after: 10 * time.Second, want: StateRacing, transition: true},
+			},
+		},
+		{
+			name: "unknown while the page loads",
+			steps: []step{
+				{detected: StateUnknown, want: StateUnknown},
+				{detected: StateMenu, after: time.Second, want: StateMenu, transition: true},
+				{detected: StateUnknown, after: 2 * time.Second, want: StateUnknown, transition: true},
+				{detected: StateMenu, after: 3 * time.Second, want: StateMenu, transition: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newStateMachine()
+			t.Cleanup(m.stop)
+
+			start := time.Now()
+			previous := StateUnknown
+
+			for i, s := range tt.steps {
+				got, transition := m.update(s.detected, start.Add(s.after), func() {})
+				if got != s.want {
+					t.Fatalf("step %d: update(%s) = %s, want %s", i, s.detected, got, s.want)
+				}
+
+				if (transition != nil) != s.transition {
+					t.Fatalf("step %d: update(%s) transition = %+v, want one: %t", i, s.detected, transition, s.transition)
+				}
+
+				if transition != nil {
+					if transition.From != previous || transition.To != s.want {
+						t.Errorf("step %d: transition %s -> %s, want %s -> %s", i, transition.From, transition.To, previous, s.want)
+					}
+
+					if transition.Timed != s.timed {
+						t.Errorf("step %d: transition timed = %t, want %t", i, transition.Timed, s.timed)
+					}
+				}
+
+				if state, _ := m.current(); state != s.want {
+					t.Errorf("step %d: current() = %s, want %s", i, state, s.want)
+				}
+
+				previous = s.want
+			}
+		})
+	}
+}
+
+func TestStateMachineRechecksTimedCountdown(t *testing.T) {
+	m := newStateMachine()
+	t.Cleanup(m.stop)
+
+	// The page left the menu while loading, and racing is detected right before the countdown should be over.
+	start := time.Now()
+	m.update(StateMenu, start, func() {})
+	m.update(StateUnknown, start, func() {})
+
+	rechecked := make(chan struct{})
+	state, _ := m.update(StateRacing, start.Add(countdownDuration-10*time.Millisecond), func() {
+		close(rechecked)
+	})
+	if state != StateCountdown {
+		t.Fatalf("update(racing) = %s, want %s", state, StateCountdown)
+	}
+
+	select {
+	case <-rechecked:
+	case <-time.After(time.Second):
+		t.Fatal("the timed countdown was never rechecked")
+	}
+
+	if state, _ := m.update(m.lastDetected(), start.Add(countdownDuration), func() {}); state != StateRacing {
+		t.Errorf("update() after the countdown = %s, want %s", state, StateRacing)
+	}
+}
+
+func TestStateMachineChanged(t *testing.T) {
+	m := newStateMachine()
+
+	_, changed := m.current()
+	m.update(StateMenu, time.Now(), func() {})
+
+	select {
+	case <-changed:
+	default:
+		t.Fatal("changed wasn't closed on a transition")
+	}
+
+	_, changed = m.current()
+	m.update(StateMenu, time.Now(), func() {})
+
+	select {
+	case <-changed:
+		t.Fatal("changed was closed without a transition")
+	default:
+	}
+}
@@ -0,0 +1,225 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// latencyBuckets are the upper bounds of the latency histograms (seconds).
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+
+// Metrics are the metrics of a session.
+// A nil *Metrics is valid and records nothing, so metrics can be disabled.
+type Metrics struct {
+	registry *Registry
+
+	agentErrors       *CounterVec
+	agentLatency      *Histogram
+	applyErrors       *Counter
+	lapsCompleted     *Counter
+	lapsFailed        *Counter
+	lastLapTime       *Gauge
+	recordingDropped  *Counter
+	recordingQueue    *Gauge
+	recordingLatency  *Histogram
+	screenshotLatency *Histogram
+	tickOverruns      *Counter
+	tickRate          *Gauge
+	ticks             *Counter
+}
+
+// New creates the metrics of a session.
+func New() *Metrics {
+	r := NewRegistry()
+
+	return &Metrics{
+		registry: r,
+
+		agentErrors: r.CounterVec(
+			"stig_agent_errors_total",
+			"Frames the agent failed to act on, by kind of error.",
+			"kind",
+		),
+		agentLatency: r.Histogram(
+			"stig_agent_latency_seconds",
+			"Time the agent took to act on a frame.",
+			latencyBuckets,
+		),
+		applyErrors: r.Counter(
+			"stig_controller_apply_errors_total",
+			"Actions the controller failed to apply.",
+		),
+		lapsCompleted: r.Counter(
+			"stig_laps_completed_total",
+			"Laps that crossed the finish line.",
+		),
+		lapsFailed: r.Counter(
+			"stig_laps_failed_total",
+			"Laps that failed, timed out or were canceled.",
+		),
+		lastLapTime: r.Gauge(
+			"stig_last_lap_time_seconds",
+			"Lap time of the last completed lap.",
+		),
+		recordingDropped: r.Counter(
+			"stig_recording_dropped_frames_total",
+			"Frames dropped because the recording queue was full.",
+		),
+		recordingQueue: r.Gauge(
+			"stig_recording_queue_frames",
+			"Frames waiting in the recording queues of all workers to be written.",
+		),
+		recordingLatency: r.Histogram(
+			"stig_recording_write_latency_seconds",
+			"Time to write a recorded frame to disk.",
+			latencyBuckets,
+		),
+		screenshotLatency: r.Histogram(
+			"stig_screenshot_latency_seconds",
+			"Time to capture a frame of the screen.",
+			latencyBuckets,
+		),
+		tickOverruns: r.Counter(
+			"stig_tick_overruns_total",
+			"Ticks of the game loop that took longer than their interval.",
+		),
+		tickRate: r.Gauge(
+			"stig_tick_rate",
+			"Ticks per second of the current game loop.",
+		),
+		ticks: r.Counter(
+			"stig_ticks_total",
+			"Ticks of the game loop.",
+		),
+	}
+}
+
+// Tick records a tick of the game loop, running at a rate (ticks per second).
+func (m *Metrics) Tick(rate float64, overrun bool) {
+	if m == nil {
+		return
+	}
+
+	m.ticks.Inc()
+	m.tickRate.Set(rate)
+	if overrun {
+		m.tickOverruns.Inc()
+	}
+}
+
+// ObserveScreenshot records the time to capture a frame.
+func (m *Metrics) ObserveScreenshot(latency time.Duration) {
+	if m == nil {
+		return
+	}
+
+	m.screenshotLatency.Observe(latency.Seconds())
+}
+
+// ObserveAgent records the time the agent took to act on a frame.
+func (m *Metrics) ObserveAgent(latency time.Duration) {
+	if m == nil {
+		return
+	}
+
+	m.agentLatency.Observe(latency.Seconds())
+}
+
+// AgentError records a frame the agent failed to act on.
+func (m *Metrics) AgentError(kind string) {
+	if m == nil {
+		return
+	}
+
+	m.agentErrors.Inc(kind)
+}
+
+// ApplyError records an action the controller failed to apply.
+func (m *Metrics) ApplyError() {
+	if m == nil {
+		return
+	}
+
+	m.applyErrors.Inc()
+}
+
+// RecordingQueued records a frame queued to be written.
+// The queues of all workers add up, so each writer only accounts for its own frames.
+func (m *Metrics) RecordingQueued() {
+	if m == nil {
+		return
+	}
+
+	m.recordingQueue.Add(1)
+}
+
+// RecordingDequeued records a queued frame taken out to be written.
+func (m *Metrics) RecordingDequeued() {
+	if m == nil {
+		return
+	}
+
+	m.recordingQueue.Add(-1)
+}
+
+// ObserveRecording records the time to write a recorded frame.
+func (m *Metrics) ObserveRecording(latency time.Duration) {
+	if m == nil {
+		return
+	}
+
+	m.recordingLatency.Observe(latency.Seconds())
+}
+
+// RecordingDropped records a frame dropped because the recording queue was full.
+func (m *Metrics) RecordingDropped() {
+	if m == nil {
+		return
+	}
+
+	m.recordingDropped.Inc()
+}
+
+// LapCompleted records a lap that crossed the finish line.
+func (m *Metrics) LapCompleted(lapTime time.Duration) {
+	if m == nil {
+		return
+	}
+
+	m.lapsCompleted.Inc()
+	m.lastLapTime.Set(lapTime.Seconds())
+}
+
+// LapFailed records a lap that didn't cross the finish line.
+func (m *Metrics) LapFailed() {
+	if m == nil {
+		return
+	}
+
+	m.lapsFailed.Inc()
+}
+
+// Serve serves the metrics on /metrics at the address until the context is done.
+func (m *Metrics) Serve(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.registry)
+
+	server := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		if err := server.Close(); err != nil {
+			log.Println(fmt.Sprintf("failed to close metrics server: %v", err))
+		}
+	}()
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve metrics: %w", err)
+	}
+
+	return nil
+}
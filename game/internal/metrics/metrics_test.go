@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRecordingQueueAddsUpWorkers(t *testing.T) {
+	m := New()
+
+	// Each worker queues its frames, and takes all but one out.
+	wg := sync.WaitGroup{}
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for range 100 {
+				m.RecordingQueued()
+			}
+
+			for range 99 {
+				m.RecordingDequeued()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	buf := bytes.Buffer{}
+	m.registry.WriteText(&buf)
+
+	if want := "stig_recording_queue_frames 4\n"; !strings.Contains(buf.String(), want) {
+		t.Errorf("metrics are missing %q:\n%s", want, buf.String())
+	}
+}
+
+func TestNilMetrics(t *testing.T) {
+	var m *Metrics
+
+	// A nil *Metrics records nothing, and doesn't panic.
+	m.RecordingQueued()
+	m.RecordingDequeued()
+	m.RecordingDropped()
+}
@@ -0,0 +1,228 @@
+// Package metrics exposes metrics in the Prometheus text format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// metric is a metric that writes itself in the text format.
+type metric interface {
+	write(w io.Writer)
+}
+
+// Registry is a set of metrics.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+// NewRegistry creates a new registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Counter registers a new counter.
+func (r *Registry) Counter(name string, help string) *Counter {
+	c := &Counter{name: name, help: help}
+	r.register(c)
+
+	return c
+}
+
+// CounterVec registers a new counter partitioned by a label.
+func (r *Registry) CounterVec(name string, help string, label string) *CounterVec {
+	c := &CounterVec{name: name, help: help, label: label, values: map[string]float64{}}
+	r.register(c)
+
+	return c
+}
+
+// Gauge registers a new gauge.
+func (r *Registry) Gauge(name string, help string) *Gauge {
+	g := &Gauge{name: name, help: help}
+	r.register(g)
+
+	return g
+}
+
+// Histogram registers a new histogram with the given upper bounds.
+func (r *Registry) Histogram(name string, help string, buckets []float64) *Histogram {
+	h := &Histogram{name: name, help: help, buckets: buckets, counts: make([]uint64, len(buckets))}
+	r.register(h)
+
+	return h
+}
+
+// WriteText writes the metrics in the text format.
+func (r *Registry) WriteText(w io.Writer) {
+	r.mu.Lock()
+	metrics := slices.Clone(r.metrics)
+	r.mu.Unlock()
+
+	for _, m := range metrics {
+		m.write(w)
+	}
+}
+
+// ServeHTTP serves the metrics in the text format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteText(w)
+}
+
+// register adds a metric to the registry.
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.metrics = append(r.metrics, m)
+}
+
+// Counter is a value that only goes up.
+type Counter struct {
+	name  string
+	help  string
+	mu    sync.Mutex
+	value float64
+}
+
+// Inc adds one to the counter.
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add adds a value to the counter.
+func (c *Counter) Add(value float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.value += value
+}
+
+func (c *Counter) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	writeHeader(w, c.name, c.help, "counter")
+	fmt.Fprintf(w, "%s %s\n", c.name, formatValue(c.value))
+}
+
+// CounterVec is a set of counters partitioned by a label.
+type CounterVec struct {
+	name   string
+	help   string
+	label  string
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// Inc adds one to the counter of a label value.
+func (c *CounterVec) Inc(value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values[value]++
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	writeHeader(w, c.name, c.help, "counter")
+	for _, value := range slices.Sorted(maps.Keys(c.values)) {
+		fmt.Fprintf(w, "%s{%s=%s} %s\n", c.name, c.label, strconv.Quote(value), formatValue(c.values[value]))
+	}
+}
+
+// Gauge is a value that goes up and down.
+type Gauge struct {
+	name  string
+	help  string
+	mu    sync.Mutex
+	value float64
+}
+
+// Set sets the gauge.
+func (g *Gauge) Set(value float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.value = value
+}
+
+// Add adds a value to the gauge, which may be negative.
+func (g *Gauge) Add(value float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.value += value
+}
+
+func (g *Gauge) write(w io.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	writeHeader(w, g.name, g.help, "gauge")
+	fmt.Fprintf(w, "%s %s\n", g.name, formatValue(g.value))
+}
+
+// Histogram counts observations in cumulative buckets.
+type Histogram struct {
+	name    string
+	help    string
+	buckets []float64
+	mu      sync.Mutex
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+// Observe adds an observation to the histogram.
+func (h *Histogram) Observe(value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, bound := range h.buckets {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+
+	h.count++
+	h.sum += value
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	writeHeader(w, h.name, h.help, "histogram")
+	for i, bound := range h.buckets {
+		fmt.Fprintf(w, "%s_bucket{le=%q} %d\n", h.name, formatValue(bound), h.counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", h.name, h.count)
+	fmt.Fprintf(w, "%s_sum %s\n", h.name, formatValue(h.sum))
+	fmt.Fprintf(w, "%s_count %d\n", h.name, h.count)
+}
+
+// writeHeader writes the help and type lines of a metric.
+func writeHeader(w io.Writer, name string, help string, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+}
+
+// formatValue formats a value like the text format expects.
+func formatValue(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
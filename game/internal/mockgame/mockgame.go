@@ -0,0 +1,27 @@
+// Package mockgame provides a stand-in for the racing game, for offline runs and tests.
+//
+// The page follows the same DOM contract as the live game:
+// a "Start" span starts a countdown then the race, Escape returns to the menu,
+// the arrow keys and WASD drive the car, and the lap time is shown once the lap
+// is finished in a div[data-your-time="true"] with an aria-label like "01:49:214".
+package mockgame
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var static embed.FS
+
+// Handler returns the handler that serves the game page.
+func Handler() http.Handler {
+	root, err := fs.Sub(static, "static")
+	if err != nil {
+		// The embedded directory is always there.
+		panic(err)
+	}
+
+	return http.FileServer(http.FS(root))
+}
@@ -0,0 +1,105 @@
+// Package mockgametest plays the mock game in a headless browser, for integration tests.
+//
+// The tests need a local Chrome or Chromium, and are skipped without one.
+package mockgametest
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
+
+	"github.com/nizarmah/stig/game/internal/controller"
+	"github.com/nizarmah/stig/game/internal/game"
+	"github.com/nizarmah/stig/game/internal/mockgame"
+)
+
+// carJS reads where the car is, and where the road is a little ahead of it.
+const carJS = `() => car && { x: car.x, center: roadCenter(car.distance + 80) }`
+
+// BrowserBin returns the browser to launch, from BROWSER_BIN or the local ones,
+// skipping the test when none is found, or when tests are short.
+func BrowserBin(t testing.TB) string {
+	t.Helper()
+
+	if testing.Short() {
+		t.Skip("plays the mock game in a browser")
+	}
+
+	if bin := os.Getenv("BROWSER_BIN"); bin != "" {
+		return bin
+	}
+
+	bin, ok := launcher.LookPath()
+	if !ok {
+		t.Skip("no browser found")
+	}
+
+	return bin
+}
+
+// Serve serves the mock game until the test ends, and returns its URL.
+func Serve(t testing.TB) string {
+	t.Helper()
+
+	server := httptest.NewServer(mockgame.Handler())
+	t.Cleanup(server.Close)
+
+	return server.URL
+}
+
+// Steer returns the action that keeps the car on the road, reading the car off the page.
+// It accelerates straight until the race starts.
+func Steer(ctx context.Context, page *rod.Page) (game.Action, error) {
+	action := game.Action{Throttle: game.ThrottleAccelerate}
+
+	res, err := page.Context(ctx).Evaluate(&rod.EvalOptions{JS: carJS, ByValue: true})
+	if err != nil {
+		return game.Action{}, err
+	}
+
+	// There's no car until the race starts.
+	if res.Value.Nil() {
+		return action, nil
+	}
+
+	switch offset := res.Value.Get("center").Num() - res.Value.Get("x").Num(); {
+	case offset > 20:
+		action.Steering = game.SteeringRight
+
+	case offset < -20:
+		action.Steering = game.SteeringLeft
+	}
+
+	return action, nil
+}
+
+// Drive steers the car with the keyboard, as a human would, until the context is done.
+// The keys are released once it's done.
+func Drive(ctx context.Context, page *rod.Page) {
+	controllerClient := controller.NewClient(page)
+	defer controllerClient.Apply(game.Action{})
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+		}
+
+		action, err := Steer(ctx, page)
+		if err != nil {
+			continue
+		}
+
+		controllerClient.Apply(action)
+	}
+}
@@ -0,0 +1,101 @@
+// Package pool distributes laps across parallel workers.
+package pool
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// LapFunc plays a lap on a worker.
+type LapFunc[R any] func(ctx context.Context, worker int, lap int) (R, error)
+
+// Result is the result of a lap.
+type Result[R any] struct {
+	// Err is the error of the lap, if any.
+	Err error
+	// Lap is the index of the lap.
+	Lap int
+	// Value is the value returned by the lap.
+	Value R
+	// Worker is the index of the worker that played the lap.
+	Worker int
+}
+
+// Run distributes a number of laps across workers, each playing one lap at a time,
+// and merges their results in lap order.
+func Run[R any](
+	ctx context.Context,
+	workers int,
+	laps int,
+	fn LapFunc[R],
+) []Result[R] {
+	results := []Result[R]{}
+	Stream(ctx, workers, laps, fn, func(result Result[R]) {
+		results = append(results, result)
+	})
+
+	slices.SortFunc(results, func(a, b Result[R]) int {
+		return a.Lap - b.Lap
+	})
+
+	return results
+}
+
+// Stream distributes laps across workers, each playing one lap at a time,
+// and hands each result to done as soon as its lap ends, one at a time.
+// Non-positive laps keep the workers playing until the context is done,
+// so the results are streamed rather than kept.
+func Stream[R any](
+	ctx context.Context,
+	workers int,
+	laps int,
+	fn LapFunc[R],
+	done func(Result[R]),
+) {
+	mu := sync.Mutex{}
+	next := 0
+
+	// nextLap claims the next lap, if any is left.
+	nextLap := func() (int, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if ctx.Err() != nil || (laps > 0 && next >= laps) {
+			return 0, false
+		}
+
+		lap := next
+		next++
+
+		return lap, true
+	}
+
+	wg := sync.WaitGroup{}
+	for worker := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				lap, ok := nextLap()
+				if !ok {
+					return
+				}
+
+				value, err := fn(ctx, worker, lap)
+
+				mu.Lock()
+				done(Result[R]{
+					Err:    err,
+					Lap:    lap,
+					Value:  value,
+					Worker: worker,
+				})
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,75 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	errOdd := errors.New("odd lap")
+
+	results := Run(context.Background(), 3, 10, func(_ context.Context, _ int, lap int) (int, error) {
+		// Later laps end first, to check the results are merged in lap order.
+		time.Sleep(time.Duration(10-lap) * time.Millisecond)
+
+		if lap%2 == 1 {
+			return lap, errOdd
+		}
+
+		return lap * lap, nil
+	})
+
+	if len(results) != 10 {
+		t.Fatalf("Run() returned %d results, want 10", len(results))
+	}
+
+	for i, result := range results {
+		if result.Lap != i {
+			t.Errorf("results[%d].Lap = %d, want %d", i, result.Lap, i)
+		}
+
+		if result.Worker < 0 || result.Worker >= 3 {
+			t.Errorf("results[%d].Worker = %d, want one of 3 workers", i, result.Worker)
+		}
+
+		if i%2 == 1 && !errors.Is(result.Err, errOdd) {
+			t.Errorf("results[%d].Err = %v, want %v", i, result.Err, errOdd)
+		}
+
+		if i%2 == 0 && (result.Err != nil || result.Value != i*i) {
+			t.Errorf("results[%d] = %d, %v, want %d", i, result.Value, result.Err, i*i)
+		}
+	}
+}
+
+func TestStreamUntilDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var played atomic.Int64
+	streamed := 0
+
+	Stream(ctx, 2, 0,
+		func(_ context.Context, _ int, lap int) (int, error) {
+			if played.Add(1) == 20 {
+				cancel()
+			}
+
+			return lap, nil
+		},
+		func(Result[int]) {
+			// The results are handed over one at a time.
+			streamed++
+		})
+
+	if streamed != int(played.Load()) {
+		t.Errorf("streamed %d results, want one per played lap (%d)", streamed, played.Load())
+	}
+
+	if streamed < 20 || streamed > 21 {
+		t.Errorf("streamed %d results, want laps to stop once the context is done", streamed)
+	}
+}
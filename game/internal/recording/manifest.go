@@ -0,0 +1,199 @@
+package recording
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/controller"
+	"github.com/nizarmah/stig/game/internal/game"
+)
+
+const (
+	// InputsFile is the name of the input timeline of a lap, in its directory.
+	InputsFile = "inputs.jsonl"
+	// ManifestFile is the name of the manifest of a lap, in its directory.
+	ManifestFile = "manifest.jsonl"
+	// SessionFile is the name of the description of a session, in its directory.
+	SessionFile = "session.json"
+)
+
+// EntryType is the type of a line of the manifest.
+type EntryType = string
+
+const (
+	// EntryFrame is a line describing a recorded frame.
+	EntryFrame EntryType = "frame"
+	// EntryLap is the last line of the manifest, describing how the lap ended.
+	EntryLap EntryType = "lap"
+)
+
+// FrameEntry describes a recorded frame, as a line of the manifest.
+type FrameEntry struct {
+	// Action is the action held when the frame was captured,
+	// resolved from the input timeline once the lap ended.
+	Action game.Action `json:"action"`
+	// Capture is the time it took to capture the frame.
+	Capture time.Duration `json:"capture_ns"`
+	// Dropped is whether ticks were dropped right before the frame, leaving a gap.
+	Dropped bool `json:"dropped"`
+	// File is the name of the frame file, in the lap directory.
+	File string `json:"file"`
+	// Offset is when the frame was captured, since the start of the lap, on the monotonic clock.
+	Offset time.Duration `json:"offset_ns"`
+	// Tick is the index of the tick within the lap.
+	Tick int `json:"tick"`
+	// Time is when the frame was captured, on the wall clock.
+	Time time.Time `json:"time"`
+	// Type is EntryFrame.
+	Type EntryType `json:"type"`
+}
+
+// LapEntry describes how the lap ended, as the last line of the manifest.
+type LapEntry struct {
+	game.LapResult
+
+	// DroppedFrames is the number of frames dropped because the recording queue was full.
+	DroppedFrames int `json:"dropped_frames"`
+	// FrameErrors is the number of frames that failed to be written.
+	FrameErrors int `json:"frame_errors"`
+	// Frames is the number of frames recorded.
+	Frames int `json:"frames"`
+	// ReplayTime is the final time shown on the Replay screen, as "MM:SS:mmm", if finished.
+	ReplayTime string `json:"replay_time,omitempty"`
+	// Type is EntryLap.
+	Type EntryType `json:"type"`
+}
+
+// Session describes a recording session.
+type Session struct {
+	// Command is the command that recorded the session.
+	Command string `json:"command"`
+	// Config is the effective configuration of the session, by environment variable.
+	Config map[string]string `json:"config"`
+	// Start is when the session started.
+	Start time.Time `json:"start"`
+}
+
+// WriteSession writes the description of a session into its directory.
+func WriteSession(dir string, session Session) error {
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, SessionFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+
+	return nil
+}
+
+// WriteInputs writes the input timeline of a lap into its directory, one JSON line per key event.
+func WriteInputs(dir string, events []controller.KeyEvent) error {
+	file, err := os.Create(filepath.Join(dir, InputsFile))
+	if err != nil {
+		return fmt.Errorf("failed to create inputs: %w", err)
+	}
+	defer file.Close()
+
+	enc := json.NewEncoder(file)
+	for _, event := range events {
+		if err := enc.Encode(event); err != nil {
+			return fmt.Errorf("failed to write inputs: %w", err)
+		}
+	}
+
+	return file.Close()
+}
+
+// LabelFrames names the written frames of a lap after the actions held when they were captured,
+// resolved from its input timeline, then describes them in its manifest.
+// It returns the number of frames that failed to be labeled, along with the last error.
+func LabelFrames(dir string, manifest *Manifest, frames []FrameEntry, timeline controller.Timeline) (int, error) {
+	failed := 0
+	var lastErr error
+
+	for _, entry := range frames {
+		entry.Action = timeline.ActionAt(entry.Time)
+
+		name := FrameName(entry.Time, entry.Action)
+		if err := os.Rename(filepath.Join(dir, entry.File), filepath.Join(dir, name)); err != nil {
+			failed++
+			lastErr = fmt.Errorf("failed to label frame: %w", err)
+			continue
+		}
+
+		entry.File = name
+		if err := manifest.WriteFrame(entry); err != nil {
+			failed++
+			lastErr = err
+		}
+	}
+
+	return failed, lastErr
+}
+
+// Manifest writes the manifest of a lap, one JSON line per frame, then one for the lap.
+type Manifest struct {
+	// mu guards the fields below.
+	mu sync.Mutex
+	// enc encodes the lines into the file.
+	enc *json.Encoder
+	// file is the manifest file.
+	file *os.File
+	// frames is the number of frames written.
+	frames int
+}
+
+// CreateManifest creates the manifest of a lap in its directory.
+func CreateManifest(dir string) (*Manifest, error) {
+	file, err := os.Create(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create manifest: %w", err)
+	}
+
+	return &Manifest{
+		enc:  json.NewEncoder(file),
+		file: file,
+	}, nil
+}
+
+// WriteFrame writes the line of a recorded frame.
+func (m *Manifest) WriteFrame(entry FrameEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry.Type = EntryFrame
+	if err := m.enc.Encode(entry); err != nil {
+		return fmt.Errorf("failed to write manifest frame: %w", err)
+	}
+
+	m.frames++
+
+	return nil
+}
+
+// WriteLap writes the last line of the manifest, describing how the lap ended,
+// and returns it with the frames counted from the manifest.
+func (m *Manifest) WriteLap(entry LapEntry) (LapEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry.Frames = m.frames
+	entry.Type = EntryLap
+
+	if err := m.enc.Encode(entry); err != nil {
+		return entry, fmt.Errorf("failed to write manifest lap: %w", err)
+	}
+
+	return entry, nil
+}
+
+// Close closes the manifest file.
+func (m *Manifest) Close() error {
+	return m.file.Close()
+}
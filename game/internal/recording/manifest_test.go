@@ -0,0 +1,89 @@
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/controller"
+	"github.com/nizarmah/stig/game/internal/game"
+)
+
+func TestLabelFrames(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Unix(1_700_000_000, 0)
+
+	// Two frames, captured before and after a press of accelerate.
+	frames := []FrameEntry{
+		{File: PendingFrameName(start), Tick: 0, Time: start},
+		{File: PendingFrameName(start.Add(100 * time.Millisecond)), Tick: 1, Time: start.Add(100 * time.Millisecond)},
+	}
+
+	for _, frame := range frames {
+		writeFile(t, filepath.Join(dir, frame.File))
+	}
+
+	// A frame that failed to be written.
+	frames = append(frames, FrameEntry{File: PendingFrameName(start.Add(time.Second)), Tick: 2, Time: start.Add(time.Second)})
+
+	accelerate := game.Action{Throttle: game.ThrottleAccelerate}
+	timeline := controller.Timeline{
+		Events: []controller.KeyEvent{
+			{Action: accelerate, Code: "KeyW", Time: start.Add(50 * time.Millisecond), Type: controller.KeyDown},
+		},
+	}
+
+	manifest, err := CreateManifest(dir)
+	if err != nil {
+		t.Fatalf("CreateManifest() error = %v", err)
+	}
+
+	failed, err := LabelFrames(dir, manifest, frames, timeline)
+	if failed != 1 || err == nil {
+		t.Errorf("LabelFrames() = %d, %v, want 1 failed frame", failed, err)
+	}
+
+	if err := manifest.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	lap, err := ReadLap(dir)
+	if err != nil {
+		t.Fatalf("ReadLap() error = %v", err)
+	}
+
+	if len(lap) != 2 {
+		t.Fatalf("ReadLap() = %d frames, want 2", len(lap))
+	}
+
+	if lap[0].Action != (game.Action{}) || lap[1].Action != accelerate {
+		t.Errorf("labels = %+v, %+v, want neutral then accelerate", lap[0].Action, lap[1].Action)
+	}
+
+	file, err := os.Open(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		t.Fatalf("failed to open manifest: %v", err)
+	}
+	defer file.Close()
+
+	entries := []FrameEntry{}
+	for scanner := bufio.NewScanner(file); scanner.Scan(); {
+		var entry FrameEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("failed to unmarshal manifest line: %v", err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("manifest has %d frames, want 2", len(entries))
+	}
+
+	if want := FrameName(frames[1].Time, accelerate); entries[1].File != want || entries[1].Action != accelerate {
+		t.Errorf("manifest entry = %+v, want file %s labeled %+v", entries[1], want, accelerate)
+	}
+}
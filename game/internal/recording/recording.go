@@ -0,0 +1,144 @@
+// Package recording provides the layout of the recorded gameplay.
+//
+// Recordings are grouped in "session_<time>" directories of "lap_<n>" directories,
+// nested in "worker_<n>" directories when several workers record in parallel.
+// Each lap is a directory of frames named "frame_<unixnano>_<throttle>_<steering>.jpeg",
+// where the throttle and steering are the actions held when the frame was captured.
+// Frames are written as "pending_<unixnano>.jpeg" during the lap, then named once it ended,
+// from the key events pushed by the page.
+// Each lap also has a "manifest.jsonl" describing its frames and how it ended,
+// an "inputs.jsonl" with every key pressed and released during the lap,
+// and each session a "session.json" with the configuration it was recorded with.
+//
+// Laps are recorded into a "staging" directory, then promoted next to it once they're kept,
+// or moved to a "quarantine" directory with a "rejection.json" explaining why.
+package recording
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/game"
+)
+
+const (
+	// framePrefix is the prefix of the frame file names.
+	framePrefix = "frame_"
+	// frameExt is the extension of the frame file names.
+	frameExt = ".jpeg"
+	// pendingPrefix is the prefix of the frame file names until they're labeled.
+	pendingPrefix = "pending_"
+)
+
+// Frame is a recorded frame.
+type Frame struct {
+	// Action is the action held when the frame was captured.
+	Action game.Action
+	// Path is the path of the frame file.
+	Path string
+	// Time is when the frame was captured.
+	Time time.Time
+}
+
+// SessionName returns the directory name of a session started at the given time.
+func SessionName(t time.Time) string {
+	return fmt.Sprintf("session_%s", t.Format(time.RFC3339))
+}
+
+// WorkerName returns the directory name of a worker.
+func WorkerName(worker int) string {
+	return fmt.Sprintf("worker_%d", worker)
+}
+
+// LapName returns the directory name of a lap.
+func LapName(lap int) string {
+	return fmt.Sprintf("lap_%d", lap)
+}
+
+// FrameName returns the file name of a frame captured at the given time.
+func FrameName(t time.Time, action game.Action) string {
+	return fmt.Sprintf(
+		"%s%d_%s_%s%s",
+		framePrefix,
+		t.UnixNano(),
+		action.Throttle,
+		action.Steering,
+		frameExt,
+	)
+}
+
+// PendingFrameName returns the file name of a frame captured at the given time, until it's labeled.
+func PendingFrameName(t time.Time) string {
+	return fmt.Sprintf("%s%d%s", pendingPrefix, t.UnixNano(), frameExt)
+}
+
+// ParseFrameName parses the capture time and action from a frame file name.
+func ParseFrameName(name string) (time.Time, game.Action, error) {
+	ext := filepath.Ext(name)
+	if !strings.HasPrefix(name, framePrefix) || (ext != ".jpeg" && ext != ".jpg") {
+		return time.Time{}, game.Action{}, fmt.Errorf("not a frame file: %q", name)
+	}
+
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(name, framePrefix), ext), "_")
+	if len(parts) != 3 {
+		return time.Time{}, game.Action{}, fmt.Errorf("malformed frame file: %q", name)
+	}
+
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, game.Action{}, fmt.Errorf("malformed frame time in %q: %w", name, err)
+	}
+
+	action := game.Action{
+		Throttle: parts[1],
+		Steering: parts[2],
+	}
+
+	if _, ok := game.ThrottleStateMap[action.Throttle]; !ok && action.Throttle != game.ThrottleNeutral {
+		return time.Time{}, game.Action{}, fmt.Errorf("unknown throttle in %q", name)
+	}
+
+	if _, ok := game.SteeringStateMap[action.Steering]; !ok && action.Steering != game.SteeringStraight {
+		return time.Time{}, game.Action{}, fmt.Errorf("unknown steering in %q", name)
+	}
+
+	return time.Unix(0, nanos), action, nil
+}
+
+// ReadLap reads the frames of a recorded lap, in capture order.
+// Files that are not frames are ignored.
+func ReadLap(dir string) ([]Frame, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lap directory %s: %w", dir, err)
+	}
+
+	frames := make([]Frame, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), framePrefix) {
+			continue
+		}
+
+		t, action, err := ParseFrameName(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		frames = append(frames, Frame{
+			Action: action,
+			Path:   filepath.Join(dir, entry.Name()),
+			Time:   t,
+		})
+	}
+
+	slices.SortFunc(frames, func(a, b Frame) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	return frames, nil
+}
@@ -0,0 +1,114 @@
+package recording
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/game"
+)
+
+func TestFrameNameRoundTrip(t *testing.T) {
+	captured := time.Unix(1_700_000_000, 123_456_789)
+
+	tests := []game.Action{
+		{},
+		{Throttle: game.ThrottleAccelerate},
+		{Steering: game.SteeringLeft},
+		{Throttle: game.ThrottleBrake, Steering: game.SteeringRight},
+	}
+
+	for _, action := range tests {
+		name := FrameName(captured, action)
+
+		t.Run(name, func(t *testing.T) {
+			gotTime, gotAction, err := ParseFrameName(name)
+			if err != nil {
+				t.Fatalf("ParseFrameName(%q) error = %v", name, err)
+			}
+
+			if !gotTime.Equal(captured) {
+				t.Errorf("ParseFrameName(%q) time = %v, want %v", name, gotTime, captured)
+			}
+
+			if gotAction != action {
+				t.Errorf("ParseFrameName(%q) action = %+v, want %+v", name, gotAction, action)
+			}
+		})
+	}
+}
+
+func TestParseFrameNameErrors(t *testing.T) {
+	tests := []string{
+		PendingFrameName(time.Unix(1, 0)),
+		"frame_1_accelerate_left.png",
+		"frame_1_accelerate.jpeg",
+		"frame_x_accelerate_left.jpeg",
+		"frame_1_boost_left.jpeg",
+		"frame_1_accelerate_drift.jpeg",
+		"manifest.jsonl",
+	}
+
+	for _, name := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := ParseFrameName(name); err == nil {
+				t.Errorf("ParseFrameName(%q) error = nil, want an error", name)
+			}
+		})
+	}
+}
+
+func TestReadLap(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Unix(999_999_999, 0)
+
+	accelerate := game.Action{Throttle: game.ThrottleAccelerate}
+	left := game.Action{Throttle: game.ThrottleAccelerate, Steering: game.SteeringLeft}
+
+	// The first frame sorts last by name, since its time has a digit less.
+	want := []Frame{
+		{Action: game.Action{}, Time: start},
+		{Action: accelerate, Time: start.Add(time.Second)},
+		{Action: left, Time: start.Add(1900 * time.Millisecond)},
+	}
+
+	for _, frame := range []Frame{want[2], want[0], want[1]} {
+		writeFile(t, filepath.Join(dir, FrameName(frame.Time, frame.Action)))
+	}
+
+	// Files that aren't frames are ignored.
+	writeFile(t, filepath.Join(dir, ManifestFile))
+	writeFile(t, filepath.Join(dir, PendingFrameName(start)))
+	if err := os.Mkdir(filepath.Join(dir, framePrefix+"dir"), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+
+	frames, err := ReadLap(dir)
+	if err != nil {
+		t.Fatalf("ReadLap() error = %v", err)
+	}
+
+	if len(frames) != len(want) {
+		t.Fatalf("ReadLap() returned %d frames, want %d", len(frames), len(want))
+	}
+
+	for i, frame := range frames {
+		if !frame.Time.Equal(want[i].Time) || frame.Action != want[i].Action {
+			t.Errorf("frame %d = %+v at %v, want %+v at %v", i, frame.Action, frame.Time, want[i].Action, want[i].Time)
+		}
+
+		if path := filepath.Join(dir, FrameName(want[i].Time, want[i].Action)); frame.Path != path {
+			t.Errorf("frame %d path = %q, want %q", i, frame.Path, path)
+		}
+	}
+}
+
+func TestReadLapMalformedFrame(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "frame_1_boost_left.jpeg"))
+
+	if _, err := ReadLap(dir); err == nil {
+		t.Error("ReadLap() error = nil, want the malformed frame reported")
+	}
+}
@@ -0,0 +1,119 @@
+package recording
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/game"
+)
+
+const (
+	// StagingDir is the directory of the laps being recorded, until they're finalized.
+	StagingDir = "staging"
+	// QuarantineDir is the directory of the laps rejected when finalized.
+	QuarantineDir = "quarantine"
+	// RejectionFile is the name of the reason a lap was rejected, in its quarantined directory.
+	RejectionFile = "rejection.json"
+)
+
+// Rules decide which recorded laps are kept.
+// Only finished laps are kept, and the zero value of a rule disables it.
+type Rules struct {
+	// MaxLapTime is the highest lap time to keep a lap.
+	MaxLapTime time.Duration
+	// MinFrames is the lowest number of frames to keep a lap.
+	MinFrames int
+}
+
+// Reject returns why a lap is rejected, or an empty string if it's kept.
+func (r Rules) Reject(entry LapEntry) string {
+	switch {
+	case entry.Status != game.LapFinished:
+		if entry.Error != "" {
+			return fmt.Sprintf("lap %s: %s", entry.Status, entry.Error)
+		}
+
+		return fmt.Sprintf("lap %s", entry.Status)
+
+	case r.MaxLapTime > 0 && entry.LapTime > r.MaxLapTime:
+		return fmt.Sprintf("lap time %s is above the max of %s", entry.LapTime, r.MaxLapTime)
+
+	case r.MinFrames > 0 && entry.Frames < r.MinFrames:
+		return fmt.Sprintf("%d frames are below the min of %d", entry.Frames, r.MinFrames)
+	}
+
+	return ""
+}
+
+// Rejection is why a lap was rejected.
+type Rejection struct {
+	// Reason is why the lap was rejected.
+	Reason string `json:"reason"`
+	// Time is when the lap was rejected.
+	Time time.Time `json:"time"`
+}
+
+// StageLap creates the staging directory of a lap, in the directory its laps are recorded in.
+// Leftovers of a previous attempt at the lap are removed.
+func StageLap(dir string, lap int) (string, error) {
+	staged := filepath.Join(dir, StagingDir, LapName(lap))
+	if err := os.RemoveAll(staged); err != nil {
+		return "", fmt.Errorf("failed to clear staged lap: %w", err)
+	}
+
+	if err := os.MkdirAll(staged, 0755); err != nil {
+		return "", fmt.Errorf("failed to create staged lap: %w", err)
+	}
+
+	return staged, nil
+}
+
+// Promote moves a staged lap to its place among the recorded laps of the directory,
+// and returns its new path.
+func Promote(dir string, staged string) (string, error) {
+	promoted := filepath.Join(dir, filepath.Base(staged))
+	if err := move(staged, promoted); err != nil {
+		return "", fmt.Errorf("failed to promote lap: %w", err)
+	}
+
+	return promoted, nil
+}
+
+// Quarantine moves a staged lap to the quarantine of the directory, with the reason it was rejected,
+// and returns its new path.
+func Quarantine(dir string, staged string, reason string) (string, error) {
+	data, err := json.MarshalIndent(Rejection{Reason: reason, Time: time.Now()}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal rejection: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(staged, RejectionFile), data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write rejection: %w", err)
+	}
+
+	quarantined := filepath.Join(dir, QuarantineDir, filepath.Base(staged))
+	if err := move(staged, quarantined); err != nil {
+		return "", fmt.Errorf("failed to quarantine lap: %w", err)
+	}
+
+	return quarantined, nil
+}
+
+// move moves a directory, without replacing an existing one.
+func move(from string, to string) error {
+	if _, err := os.Stat(to); err == nil {
+		return fmt.Errorf("%s already exists", to)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return err
+	}
+
+	return os.Rename(from, to)
+}
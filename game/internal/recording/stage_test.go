@@ -0,0 +1,230 @@
+package recording
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/game"
+)
+
+func TestRulesReject(t *testing.T) {
+	finished := func(lapTime time.Duration, frames int) LapEntry {
+		return LapEntry{
+			LapResult: game.LapResult{LapTime: lapTime, Status: game.LapFinished},
+			Frames:    frames,
+		}
+	}
+
+	tests := []struct {
+		name  string
+		rules Rules
+		entry LapEntry
+		want  string
+	}{
+		{
+			name:  "finished lap without rules",
+			entry: finished(2*time.Minute, 0),
+			want:  "",
+		},
+		{
+			name:  "timed out lap",
+			entry: LapEntry{LapResult: game.LapResult{Status: game.LapTimedOut}},
+			want:  "lap timed_out",
+		},
+		{
+			name: "failed lap with an error",
+			entry: LapEntry{LapResult: game.LapResult{
+				Error:  "failed to reset game",
+				Status: game.LapFailed,
+			}},
+			want: "lap failed: failed to reset game",
+		},
+		{
+			name:  "canceled lap under the rules",
+			rules: Rules{MaxLapTime: time.Minute, MinFrames: 10},
+			entry: LapEntry{LapResult: game.LapResult{Status: game.LapCanceled}, Frames: 100},
+			want:  "lap canceled",
+		},
+		{
+			name:  "lap time at the max",
+			rules: Rules{MaxLapTime: time.Minute},
+			entry: finished(time.Minute, 0),
+			want:  "",
+		},
+		{
+			name:  "lap time above the max",
+			rules: Rules{MaxLapTime: time.Minute},
+			entry: finished(time.Minute+time.Millisecond, 0),
+			want:  "lap time 1m0.001s is above the max of 1m0s",
+		},
+		{
+			name:  "frames at the min",
+			rules: Rules{MinFrames: 10},
+			entry: finished(time.Minute, 10),
+			want:  "",
+		},
+		{
+			name:  "frames below the min",
+			rules: Rules{MinFrames: 10},
+			entry: finished(time.Minute, 9),
+			want:  "9 frames are below the min of 10",
+		},
+		{
+			name:  "lap time is checked before frames",
+			rules: Rules{MaxLapTime: time.Minute, MinFrames: 10},
+			entry: finished(2*time.Minute, 0),
+			want:  "lap time 2m0s is above the max of 1m0s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rules.Reject(tt.entry); got != tt.want {
+				t.Errorf("Reject() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStageLap(t *testing.T) {
+	dir := t.TempDir()
+
+	staged, err := StageLap(dir, 3)
+	if err != nil {
+		t.Fatalf("StageLap() error = %v", err)
+	}
+
+	if want := filepath.Join(dir, StagingDir, "lap_3"); staged != want {
+		t.Errorf("StageLap() = %q, want %q", staged, want)
+	}
+
+	// Leave a frame behind, as an interrupted attempt would.
+	writeFile(t, filepath.Join(staged, "frame.jpeg"))
+
+	staged, err = StageLap(dir, 3)
+	if err != nil {
+		t.Fatalf("StageLap() again error = %v", err)
+	}
+
+	if entries := readDir(t, staged); len(entries) != 0 {
+		t.Errorf("StageLap() left %v from the previous attempt", entries)
+	}
+}
+
+func TestPromote(t *testing.T) {
+	dir := t.TempDir()
+
+	staged, err := StageLap(dir, 1)
+	if err != nil {
+		t.Fatalf("StageLap() error = %v", err)
+	}
+
+	writeFile(t, filepath.Join(staged, ManifestFile))
+
+	promoted, err := Promote(dir, staged)
+	if err != nil {
+		t.Fatalf("Promote() error = %v", err)
+	}
+
+	if want := filepath.Join(dir, "lap_1"); promoted != want {
+		t.Errorf("Promote() = %q, want %q", promoted, want)
+	}
+
+	if _, err := os.Stat(filepath.Join(promoted, ManifestFile)); err != nil {
+		t.Errorf("promoted lap is missing its manifest: %v", err)
+	}
+
+	if _, err := os.Stat(staged); !os.IsNotExist(err) {
+		t.Errorf("staged lap still exists after Promote(): %v", err)
+	}
+
+	// A lap is never promoted over an existing one.
+	staged, err = StageLap(dir, 1)
+	if err != nil {
+		t.Fatalf("StageLap() again error = %v", err)
+	}
+
+	if _, err := Promote(dir, staged); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Promote() over an existing lap error = %v, want already exists", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(promoted, ManifestFile)); err != nil {
+		t.Errorf("existing lap lost its manifest: %v", err)
+	}
+}
+
+func TestQuarantine(t *testing.T) {
+	dir := t.TempDir()
+
+	staged, err := StageLap(dir, 2)
+	if err != nil {
+		t.Fatalf("StageLap() error = %v", err)
+	}
+
+	writeFile(t, filepath.Join(staged, ManifestFile))
+
+	quarantined, err := Quarantine(dir, staged, "lap timed_out")
+	if err != nil {
+		t.Fatalf("Quarantine() error = %v", err)
+	}
+
+	if want := filepath.Join(dir, QuarantineDir, "lap_2"); quarantined != want {
+		t.Errorf("Quarantine() = %q, want %q", quarantined, want)
+	}
+
+	if _, err := os.Stat(filepath.Join(quarantined, ManifestFile)); err != nil {
+		t.Errorf("quarantined lap is missing its manifest: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "lap_2")); !os.IsNotExist(err) {
+		t.Errorf("quarantined lap is among the recorded laps: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(quarantined, RejectionFile))
+	if err != nil {
+		t.Fatalf("failed to read rejection: %v", err)
+	}
+
+	var rejection Rejection
+	if err := json.Unmarshal(data, &rejection); err != nil {
+		t.Fatalf("failed to unmarshal rejection: %v", err)
+	}
+
+	if rejection.Reason != "lap timed_out" {
+		t.Errorf("rejection reason = %q, want %q", rejection.Reason, "lap timed_out")
+	}
+
+	if rejection.Time.IsZero() {
+		t.Error("rejection time is zero")
+	}
+}
+
+// writeFile writes an empty file.
+func writeFile(t *testing.T, path string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+// readDir returns the names of the entries of a directory.
+func readDir(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", dir, err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	return names
+}
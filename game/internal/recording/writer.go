@@ -0,0 +1,157 @@
+package recording
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nizarmah/stig/game/internal/metrics"
+)
+
+// WriterConfiguration is the configuration for the writer.
+type WriterConfiguration struct {
+	// Dir is the directory of the lap to write the frames into.
+	Dir string
+	// Metrics are the metrics of the session.
+	Metrics *metrics.Metrics
+	// QueueSize is the number of frames that can wait to be written before new ones are dropped.
+	QueueSize int
+}
+
+// WriterStats counts the frames of a writer.
+type WriterStats struct {
+	// Dropped is the number of frames dropped because the queue was full.
+	Dropped int
+	// Errors is the number of frames that failed to be written.
+	Errors int
+	// Written is the number of frames written.
+	Written int
+}
+
+// Writer writes the frames of a lap in the background, in order,
+// so a slow disk never holds up the game loop.
+// It keeps the entries of the written frames, to describe them in the manifest once they're labeled.
+type Writer struct {
+	dir     string
+	metrics *metrics.Metrics
+	queue   chan pendingFrame
+	done    chan struct{}
+
+	// mu guards the fields below.
+	mu sync.Mutex
+	// closed is whether the writer stopped accepting frames.
+	closed bool
+	// frames are the entries of the written frames, in order.
+	frames []FrameEntry
+	// lastErr is the last error writing a frame.
+	lastErr error
+	// stats counts the frames.
+	stats WriterStats
+}
+
+// pendingFrame is a frame waiting to be written.
+type pendingFrame struct {
+	data  []byte
+	entry FrameEntry
+}
+
+// NewWriter creates a new writer, writing until it's closed.
+func NewWriter(cfg WriterConfiguration) *Writer {
+	w := &Writer{
+		dir:     cfg.Dir,
+		metrics: cfg.Metrics,
+		queue:   make(chan pendingFrame, max(1, cfg.QueueSize)),
+		done:    make(chan struct{}),
+	}
+
+	go w.run()
+
+	return w
+}
+
+// Write queues a frame and its entry, without waiting for it to be written.
+// It returns false if the frame was dropped because the queue is full.
+func (w *Writer) Write(data []byte, entry FrameEntry) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		w.stats.Dropped++
+		return false
+	}
+
+	// Count the frame before queuing it, so it's never taken out before it's counted.
+	w.metrics.RecordingQueued()
+
+	select {
+	case w.queue <- pendingFrame{data: data, entry: entry}:
+		return true
+
+	default:
+		w.stats.Dropped++
+		w.metrics.RecordingDequeued()
+		w.metrics.RecordingDropped()
+		return false
+	}
+}
+
+// Close writes the frames still queued, then returns the stats of the writer
+// along with the last error writing a frame, if any.
+func (w *Writer) Close() (WriterStats, error) {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+
+	<-w.done
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.stats, w.lastErr
+}
+
+// run writes the queued frames until the queue is closed and drained.
+func (w *Writer) run() {
+	defer close(w.done)
+
+	for frame := range w.queue {
+		w.metrics.RecordingDequeued()
+
+		start := time.Now()
+		err := w.write(frame)
+		w.metrics.ObserveRecording(time.Since(start))
+
+		w.mu.Lock()
+		if err != nil {
+			w.stats.Errors++
+			w.lastErr = err
+		} else {
+			w.stats.Written++
+			w.frames = append(w.frames, frame.entry)
+		}
+		w.mu.Unlock()
+	}
+}
+
+// Frames returns the entries of the written frames, in order.
+func (w *Writer) Frames() []FrameEntry {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return slices.Clone(w.frames)
+}
+
+// write writes a frame.
+func (w *Writer) write(frame pendingFrame) error {
+	if err := os.WriteFile(filepath.Join(w.dir, frame.entry.File), frame.data, 0644); err != nil {
+		return fmt.Errorf("failed to write frame: %w", err)
+	}
+
+	return nil
+}
@@ -11,12 +11,15 @@ import (
 
 	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/proto"
+	"github.com/nizarmah/stig/game/internal/metrics"
 )
 
 // ClientConfiguration is the configuration for the screen client.
 type ClientConfiguration struct {
 	// Debug is whether to save the snapshot to a file.
 	Debug bool
+	// Metrics records the time to capture each frame, if set.
+	Metrics *metrics.Metrics
 	// Page is the page of the game.
 	Page *rod.Page
 	// Resolution is the resolution of the screen (0 to 100).
@@ -31,6 +34,8 @@ type ClientConfiguration struct {
 type Client struct {
 	// Debug is whether to save the snapshot to a file.
 	debug bool
+	// metrics records the time to capture each frame, if set.
+	metrics *metrics.Metrics
 	// Page is the page of the game.
 	page *rod.Page
 	// Resolution is the resolution of the screen (0 to 100).
@@ -45,6 +50,7 @@ type Client struct {
 func NewClient(cfg ClientConfiguration) *Client {
 	return &Client{
 		debug:        cfg.Debug,
+		metrics:      cfg.Metrics,
 		page:         cfg.Page,
 		resolution:   cfg.Resolution,
 		windowHeight: cfg.WindowHeight,
@@ -54,6 +60,8 @@ func NewClient(cfg ClientConfiguration) *Client {
 
 // Peek takes a snapshot of the screen.
 func (c *Client) Peek(ctx context.Context) ([]byte, error) {
+	start := time.Now()
+
 	imageData, err := c.page.
 		Context(ctx).
 		Screenshot(true, &proto.PageCaptureScreenshot{
@@ -74,6 +82,8 @@ func (c *Client) Peek(ctx context.Context) ([]byte, error) {
 		return nil, fmt.Errorf("failed to take snapshot: %w", err)
 	}
 
+	c.metrics.ObserveScreenshot(time.Since(start))
+
 	if c.debug {
 		if err := saveSnapshot(imageData); err != nil {
 			log.Printf("failed to save snapshot: %v", err)
@@ -0,0 +1,98 @@
+package stats
+
+import (
+	"cmp"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+)
+
+// BootstrapMean estimates the confidence interval (0 to 1) of the mean of the samples,
+// from the means of the given number of resamples.
+func BootstrapMean(
+	samples []time.Duration,
+	confidence float64,
+	resamples int,
+	rng *rand.Rand,
+) (low time.Duration, high time.Duration) {
+	if len(samples) == 0 || resamples <= 0 {
+		return 0, 0
+	}
+
+	means := make([]time.Duration, resamples)
+	for i := range means {
+		var total time.Duration
+		for range samples {
+			total += samples[rng.IntN(len(samples))]
+		}
+
+		means[i] = total / time.Duration(len(samples))
+	}
+
+	slices.Sort(means)
+
+	alpha := 1 - confidence
+	return Percentile(means, 100*alpha/2), Percentile(means, 100*(1-alpha/2))
+}
+
+// MannWhitney tests whether the samples of a tend to differ from the samples of b.
+// It returns the U statistic of a, and the two-sided p-value
+// from the normal approximation with tie and continuity corrections.
+func MannWhitney(a []time.Duration, b []time.Duration) (u float64, p float64) {
+	n1, n2 := float64(len(a)), float64(len(b))
+	if n1 == 0 || n2 == 0 {
+		return 0, 1
+	}
+
+	// Rank the pooled samples, averaging the ranks of ties.
+	type sample struct {
+		value time.Duration
+		fromA bool
+	}
+
+	pooled := make([]sample, 0, len(a)+len(b))
+	for _, value := range a {
+		pooled = append(pooled, sample{value: value, fromA: true})
+	}
+	for _, value := range b {
+		pooled = append(pooled, sample{value: value})
+	}
+
+	slices.SortFunc(pooled, func(x, y sample) int {
+		return cmp.Compare(x.value, y.value)
+	})
+
+	rankSumA, tieTerm := 0.0, 0.0
+	for i := 0; i < len(pooled); {
+		j := i
+		for j < len(pooled) && pooled[j].value == pooled[i].value {
+			j++
+		}
+
+		// Ranks i+1 through j share their average.
+		rank := float64(i+1+j) / 2
+		for k := i; k < j; k++ {
+			if pooled[k].fromA {
+				rankSumA += rank
+			}
+		}
+
+		ties := float64(j - i)
+		tieTerm += ties*ties*ties - ties
+		i = j
+	}
+
+	u = rankSumA - n1*(n1+1)/2
+
+	n := n1 + n2
+	mean := n1 * n2 / 2
+	variance := n1 * n2 / 12 * ((n + 1) - tieTerm/(n*(n-1)))
+	if variance <= 0 {
+		return u, 1
+	}
+
+	z := math.Max(math.Abs(u-mean)-0.5, 0) / math.Sqrt(variance)
+
+	return u, math.Erfc(z / math.Sqrt2)
+}
@@ -0,0 +1,136 @@
+package stats
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+func TestMannWhitney(t *testing.T) {
+	tests := []struct {
+		name  string
+		a     []time.Duration
+		b     []time.Duration
+		wantU float64
+		wantP float64
+	}{
+		{
+			name:  "fully separated",
+			a:     []time.Duration{1, 2, 3},
+			b:     []time.Duration{4, 5, 6},
+			wantU: 0,
+			wantP: 0.080856,
+		},
+		{
+			// The textbook example, whose smaller U of 3 is above the critical value of 1 for n1=5, n2=4.
+			name:  "textbook",
+			a:     []time.Duration{19, 22, 16, 29, 24},
+			b:     []time.Duration{20, 11, 17, 12},
+			wantU: 17,
+			wantP: 0.111347,
+		},
+		{
+			name:  "ties",
+			a:     []time.Duration{1, 2, 2, 3},
+			b:     []time.Duration{2, 3, 4, 5},
+			wantU: 2.5,
+			wantP: 0.136658,
+		},
+		{
+			name:  "identical",
+			a:     []time.Duration{1, 2, 3, 4},
+			b:     []time.Duration{1, 2, 3, 4},
+			wantU: 8,
+			wantP: 1,
+		},
+		{
+			name:  "all tied",
+			a:     []time.Duration{5, 5},
+			b:     []time.Duration{5, 5, 5},
+			wantU: 3,
+			wantP: 1,
+		},
+		{
+			name:  "no samples",
+			a:     nil,
+			b:     []time.Duration{1, 2},
+			wantU: 0,
+			wantP: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, p := MannWhitney(tt.a, tt.b)
+			if u != tt.wantU {
+				t.Errorf("MannWhitney() u = %v, want %v", u, tt.wantU)
+			}
+
+			if math.Abs(p-tt.wantP) > 1e-6 {
+				t.Errorf("MannWhitney() p = %.6f, want %.6f", p, tt.wantP)
+			}
+		})
+	}
+}
+
+func TestMannWhitneyIsSymmetric(t *testing.T) {
+	a := []time.Duration{19, 22, 16, 29, 24}
+	b := []time.Duration{20, 11, 17, 12}
+
+	uA, pA := MannWhitney(a, b)
+	uB, pB := MannWhitney(b, a)
+
+	if uA+uB != float64(len(a)*len(b)) {
+		t.Errorf("u of a (%v) and u of b (%v) don't add up to %d", uA, uB, len(a)*len(b))
+	}
+
+	if pA != pB {
+		t.Errorf("p of a (%v) differs from p of b (%v)", pA, pB)
+	}
+}
+
+func TestBootstrapMean(t *testing.T) {
+	// The samples 1ms to 100ms have a mean of 50.5ms and a standard error of about 2.887ms,
+	// so the 95% interval is about 50.5ms ± 1.96 × 2.887ms.
+	samples := []time.Duration{}
+	for i := 1; i <= 100; i++ {
+		samples = append(samples, time.Duration(i)*time.Millisecond)
+	}
+
+	low, high := BootstrapMean(samples, 0.95, 10000, rand.New(rand.NewPCG(1, 2)))
+
+	wantLow, wantHigh := 44840*time.Microsecond, 56160*time.Microsecond
+	tolerance := 500 * time.Microsecond
+
+	if (low - wantLow).Abs() > tolerance {
+		t.Errorf("BootstrapMean() low = %v, want %v ± %v", low, wantLow, tolerance)
+	}
+
+	if (high - wantHigh).Abs() > tolerance {
+		t.Errorf("BootstrapMean() high = %v, want %v ± %v", high, wantHigh, tolerance)
+	}
+
+	// The same seed gives the same interval.
+	againLow, againHigh := BootstrapMean(samples, 0.95, 10000, rand.New(rand.NewPCG(1, 2)))
+	if againLow != low || againHigh != high {
+		t.Errorf("BootstrapMean() with the same seed = [%v, %v], want [%v, %v]", againLow, againHigh, low, high)
+	}
+}
+
+func TestBootstrapMeanEdgeCases(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	constant := []time.Duration{time.Second, time.Second, time.Second}
+	if low, high := BootstrapMean(constant, 0.95, 100, rng); low != time.Second || high != time.Second {
+		t.Errorf("BootstrapMean(constant) = [%v, %v], want [1s, 1s]", low, high)
+	}
+
+	if low, high := BootstrapMean(nil, 0.95, 100, rng); low != 0 || high != 0 {
+		t.Errorf("BootstrapMean(nil) = [%v, %v], want [0, 0]", low, high)
+	}
+
+	if low, high := BootstrapMean(constant, 0.95, 0, rng); low != 0 || high != 0 {
+		t.Errorf("BootstrapMean() without resamples = [%v, %v], want [0, 0]", low, high)
+	}
+}
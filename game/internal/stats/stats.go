@@ -0,0 +1,109 @@
+// Package stats provides statistics over measurements.
+package stats
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Durations collects duration samples.
+// It is safe for concurrent use.
+type Durations struct {
+	// mu guards samples.
+	mu sync.Mutex
+	// samples are the collected samples.
+	samples []time.Duration
+}
+
+// Add adds a sample.
+func (d *Durations) Add(sample time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.samples = append(d.samples, sample)
+}
+
+// Samples returns a copy of the collected samples.
+func (d *Durations) Samples() []time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return slices.Clone(d.samples)
+}
+
+// Summary summarizes the collected samples.
+func (d *Durations) Summary() Summary {
+	return Summarize(d.Samples())
+}
+
+// Summary summarizes duration samples.
+type Summary struct {
+	// Count is the number of samples.
+	Count int `json:"count"`
+	// Min is the smallest sample.
+	Min time.Duration `json:"min"`
+	// Mean is the average of the samples.
+	Mean time.Duration `json:"mean"`
+	// P50 is the median of the samples.
+	P50 time.Duration `json:"p50"`
+	// P90 is the 90th percentile of the samples.
+	P90 time.Duration `json:"p90"`
+	// P99 is the 99th percentile of the samples.
+	P99 time.Duration `json:"p99"`
+	// Max is the largest sample.
+	Max time.Duration `json:"max"`
+}
+
+// Summarize summarizes duration samples.
+func Summarize(samples []time.Duration) Summary {
+	if len(samples) == 0 {
+		return Summary{}
+	}
+
+	sorted := slices.Clone(samples)
+	slices.Sort(sorted)
+
+	var total time.Duration
+	for _, sample := range sorted {
+		total += sample
+	}
+
+	return Summary{
+		Count: len(sorted),
+		Min:   sorted[0],
+		Mean:  total / time.Duration(len(sorted)),
+		P50:   Percentile(sorted, 50),
+		P90:   Percentile(sorted, 90),
+		P99:   Percentile(sorted, 99),
+		Max:   sorted[len(sorted)-1],
+	}
+}
+
+// String formats the summary for logs.
+func (s Summary) String() string {
+	return fmt.Sprintf(
+		"n=%d mean=%v p50=%v p90=%v p99=%v max=%v",
+		s.Count,
+		s.Mean.Round(time.Microsecond),
+		s.P50.Round(time.Microsecond),
+		s.P90.Round(time.Microsecond),
+		s.P99.Round(time.Microsecond),
+		s.Max.Round(time.Microsecond),
+	)
+}
+
+// Percentile returns the p-th percentile (0 to 100) of sorted samples,
+// using the nearest-rank method.
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	rank = min(max(rank, 1), len(sorted))
+
+	return sorted[rank-1]
+}
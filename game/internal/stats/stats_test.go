@@ -0,0 +1,59 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	// The nearest-rank example of the textbook definition.
+	sorted := []time.Duration{15, 20, 35, 40, 50}
+
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{p: 0, want: 15},
+		{p: 5, want: 15},
+		{p: 30, want: 20},
+		{p: 40, want: 20},
+		{p: 50, want: 35},
+		{p: 90, want: 50},
+		{p: 100, want: 50},
+	}
+
+	for _, tt := range tests {
+		if got := Percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("Percentile(%v, %v) = %v, want %v", sorted, tt.p, got, tt.want)
+		}
+	}
+
+	if got := Percentile(nil, 50); got != 0 {
+		t.Errorf("Percentile(nil, 50) = %v, want 0", got)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	samples := []time.Duration{}
+	for i := 100; i >= 1; i-- {
+		samples = append(samples, time.Duration(i)*time.Millisecond)
+	}
+
+	want := Summary{
+		Count: 100,
+		Min:   time.Millisecond,
+		Mean:  50500 * time.Microsecond,
+		P50:   50 * time.Millisecond,
+		P90:   90 * time.Millisecond,
+		P99:   99 * time.Millisecond,
+		Max:   100 * time.Millisecond,
+	}
+
+	if got := Summarize(samples); got != want {
+		t.Errorf("Summarize() = %+v, want %+v", got, want)
+	}
+
+	if got := Summarize(nil); got != (Summary{}) {
+		t.Errorf("Summarize(nil) = %+v, want the zero summary", got)
+	}
+}
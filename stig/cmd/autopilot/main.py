@@ -5,8 +5,8 @@ Command autopilot exposes an API to decide what to do in the game.
 from pathlib import Path
 from typing import Tuple
 
-import torch, uvicorn
-from fastapi import FastAPI, Body, HTTPException
+import json, struct, torch, uvicorn
+from fastapi import FastAPI, Body, HTTPException, WebSocket, WebSocketDisconnect
 from pydantic import BaseModel, Field
 
 from stig.internal.env import env
@@ -17,6 +17,20 @@ class ActResp(BaseModel):
     throttle: str
     steering: str
 
+class ImageInfo(BaseModel):
+    format: str
+    height: int
+    width: int
+
+class ActionsInfo(BaseModel):
+    throttle: list[str]
+    steering: list[str]
+
+class InfoResp(BaseModel):
+    model: str
+    image: ImageInfo
+    actions: ActionsInfo
+
 def create_app(
     model_name: str,
     models_dir: str,
@@ -38,10 +52,7 @@ def create_app(
     # Create FastAPI app.
     app = FastAPI(title="Stig Autopilot")
 
-    @app.post("/act")
-    async def act(
-        img_bytes: bytes = Body(..., media_type="image/jpeg")
-    ) -> ActResp:
+    def predict(img_bytes: bytes) -> ActResp:
         # Process the image.
         try:
             img = process_from_bytes(img_bytes, frame_size, device)
@@ -63,6 +74,52 @@ def create_app(
             steering=STEERING_VALUES_MAP[steering_id],
         )
 
+    @app.get("/info")
+    async def info() -> InfoResp:
+        """
+        Describes the model so clients can check they are compatible before playing.
+        """
+        return InfoResp(
+            model=model_name,
+            image=ImageInfo(format="jpeg", height=frame_size[0], width=frame_size[1]),
+            actions=ActionsInfo(
+                throttle=list(THROTTLE_VALUES_MAP.values()),
+                steering=list(STEERING_VALUES_MAP.values()),
+            ),
+        )
+
+    @app.post("/act")
+    async def act(
+        img_bytes: bytes = Body(..., media_type="image/jpeg")
+    ) -> ActResp:
+        return predict(img_bytes)
+
+    @app.websocket("/stream")
+    async def stream(ws: WebSocket):
+        """
+        Each message is a big-endian uint64 sequence number followed by the JPEG frame.
+        Each answer is the action as JSON, tagged with the same sequence number.
+        """
+        await ws.accept()
+
+        try:
+            while True:
+                msg = await ws.receive_bytes()
+                if len(msg) < 8:
+                    continue
+
+                (seq,) = struct.unpack(">Q", msg[:8])
+
+                # Skip frames that fail, the client falls back on its own.
+                try:
+                    resp = predict(msg[8:])
+                except HTTPException:
+                    continue
+
+                await ws.send_text(json.dumps({"seq": seq, **resp.model_dump()}))
+        except WebSocketDisconnect:
+            return
+
     return app
 
 if __name__ == "__main__":
@@ -15,6 +15,9 @@ FRAME_RE = re.compile(
     re.IGNORECASE,
 )
 
+# Laps still being recorded, or rejected, are kept out of the dataset.
+EXCLUDED_DIRS = {"staging", "quarantine"}
+
 def get_or_create_dataset(
     model_name: str,
     datasets_dir: str,
@@ -95,8 +98,11 @@ def load_dataset(imgs_path: str, meta_path: str) -> data.TensorDataset:
     return dataset
 
 def _all_frames(root: Path) -> list[Path]:
-    """Every file whose **name** matches FRAME_RE (any depth)."""
-    return sorted(p for p in root.rglob("*") if FRAME_RE.match(p.name))
+    """Every file whose **name** matches FRAME_RE (any depth), outside EXCLUDED_DIRS."""
+    return sorted(
+        p for p in root.rglob("*")
+        if FRAME_RE.match(p.name) and not EXCLUDED_DIRS.intersection(p.relative_to(root).parts)
+    )
 
 def _newest_mtime(root: Path) -> float:
     """Most recent mtime among all frame files."""